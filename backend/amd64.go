@@ -0,0 +1,165 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Hardware registers backing imp registers 0..7. Imp programs never call out
+// to foreign code, so no calling convention constrains the choice. %rax is
+// left free as a scratch register for immediates that don't fit in 32 bits.
+var amd64Regs = [...]string{
+	"%r8", "%r9", "%r10", "%r11", "%r12", "%r13", "%r14", "%r15",
+}
+
+func init() {
+	Register(target{
+		name:  "amd64",
+		regs:  len(amd64Regs),
+		args:  6,
+		lower: lowerAmd64,
+	})
+}
+
+// Lowers psuedo-instructions to x86-64 GNU assembler (AT&T syntax) for Linux.
+// Execution begins at _start with the first psuedo-instruction and the
+// process exits with register 0 as its status once control reaches the end
+// of the program.
+func lowerAmd64(psuedo []Ins) ([]byte, error) {
+	var b strings.Builder
+
+	b.WriteString("\t.text\n")
+	b.WriteString("\t.globl _start\n")
+	b.WriteString("_start:\n")
+
+	for i, ins := range psuedo {
+		b.WriteString(fmt.Sprintf(".L%d:\t\t# %s\n", i, ins))
+		asm, err := amd64Ins(ins)
+		if err != nil {
+			return nil, errors.New("ins %d (%s): %s", i, ins, err)
+		}
+		for _, line := range asm {
+			b.WriteString("\t" + line + "\n")
+		}
+	}
+
+	// Exit with register 0 as the status.
+	b.WriteString(fmt.Sprintf(".L%d:\n", len(psuedo)))
+	b.WriteString(fmt.Sprintf("\tmovq %s, %%rdi\n", amd64Regs[0]))
+	b.WriteString("\tmovl $60, %eax\n")
+	b.WriteString("\tsyscall\n")
+
+	return []byte(b.String()), nil
+}
+
+// Returns the assembly lines implementing a single psuedo-instruction.
+func amd64Ins(ins Ins) ([]string, error) {
+	switch ins.Op {
+	case MoveI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		if fitsInt32(n) {
+			return []string{fmt.Sprintf("movq $%d, %s", n, amd64Regs[r])}, nil
+		}
+		return []string{fmt.Sprintf("movabsq $%d, %s", n, amd64Regs[r])}, nil
+	case MoveR, AddR, SubR:
+		src, dst, err := regReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("%s %s, %s", amd64Arith[ins.Op], amd64Regs[src], amd64Regs[dst]),
+		}, nil
+	case AddI, SubI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		return amd64Imm(amd64Arith[ins.Op], n, amd64Regs[r]), nil
+	case BneI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := insAddr(ins, 2)
+		if err != nil {
+			return nil, err
+		}
+		return append(
+			amd64Imm("cmpq", n, amd64Regs[r]),
+			fmt.Sprintf("jne .L%d", addr),
+		), nil
+	case BneR:
+		r0, r1, err := regReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := insAddr(ins, 2)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("cmpq %s, %s", amd64Regs[r0], amd64Regs[r1]),
+			fmt.Sprintf("jne .L%d", addr),
+		}, nil
+	case CallI, JumpI, TailI:
+		addr, err := insAddr(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		if ins.Op == CallI {
+			return []string{fmt.Sprintf("call .L%d", addr)}, nil
+		}
+		return []string{fmt.Sprintf("jmp .L%d", addr)}, nil
+	case Ret:
+		return []string{"ret"}, nil
+	case PushI:
+		n, err := insNum(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		if fitsInt32(n) {
+			return []string{fmt.Sprintf("pushq $%d", n)}, nil
+		}
+		return []string{fmt.Sprintf("movabsq $%d, %%rax", n), "pushq %rax"}, nil
+	case PushR, PopR:
+		r, err := insReg(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		if ins.Op == PushR {
+			return []string{"pushq " + amd64Regs[r]}, nil
+		}
+		return []string{"popq " + amd64Regs[r]}, nil
+	}
+	return nil, errors.Unsupported("%s instructions", ins.Op)
+}
+
+var amd64Arith = map[Opcode]string{
+	MoveR: "movq",
+	AddR:  "addq",
+	AddI:  "addq",
+	SubR:  "subq",
+	SubI:  "subq",
+}
+
+// Applies op with an immediate source, going through %rax when the immediate
+// can't be sign-extended from 32 bits.
+func amd64Imm(op string, n Num, dst string) []string {
+	if fitsInt32(n) {
+		return []string{fmt.Sprintf("%s $%d, %s", op, n, dst)}
+	}
+	return []string{
+		fmt.Sprintf("movabsq $%d, %%rax", n),
+		fmt.Sprintf("%s %%rax, %s", op, dst),
+	}
+}
+
+func fitsInt32(n Num) bool {
+	return n >= math.MinInt32 && n <= math.MaxInt32
+}
@@ -1,5 +1,11 @@
 package backend
 
+import (
+	"sort"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
 var (
 	MaxRegCount int = 8
 	MaxArgCount int = 6
@@ -9,3 +15,103 @@ var (
 var (
 	TargetArchitectureFlag string
 )
+
+// A target architecture that psuedo-instructions can be lowered to. Targets
+// make themselves available by calling Register from an init function, so a
+// new lowering never needs to touch Flatten.
+type Target interface {
+	// Name used to select the target with -arch.
+	Name() string
+
+	// Number of registers in the target's register file.
+	RegCount() int
+
+	// Maximum number of arguments a procedure can be declared with.
+	ArgCount() int
+
+	// Translates psuedo-instructions into the target's output format.
+	Lower(psuedo []Ins) ([]byte, error)
+}
+
+// Implemented by targets whose output can be run directly, so that it is
+// written with execute permissions.
+type Executable interface {
+	Executable() bool
+}
+
+// Reports whether the output of a target can be run directly.
+func IsExecutable(t Target) bool {
+	e, ok := t.(Executable)
+	return ok && e.Executable()
+}
+
+// Minimal Target implementation for lowerings that need no state of their own.
+type target struct {
+	name  string
+	regs  int
+	args  int
+	exec  bool
+	lower func([]Ins) ([]byte, error)
+}
+
+func (t target) Name() string                   { return t.name }
+func (t target) RegCount() int                  { return t.regs }
+func (t target) ArgCount() int                  { return t.args }
+func (t target) Lower(ps []Ins) ([]byte, error) { return t.lower(ps) }
+func (t target) Executable() bool               { return t.exec }
+
+var targets = make(map[string]Target)
+
+func init() {
+	Register(target{
+		name: "psuedo",
+		regs: 8,
+		args: 6,
+		lower: func(psuedo []Ins) ([]byte, error) {
+			return []byte(DumpPsuedo(psuedo) + "\n"), nil
+		},
+	})
+}
+
+// Makes a target selectable by name. Registering two targets with the same
+// name is a programming error.
+func Register(t Target) {
+	if _, ok := targets[t.Name()]; ok {
+		panic("backend: target registered twice: " + t.Name())
+	}
+	targets[t.Name()] = t
+}
+
+// Returns the target registered under name.
+func LookupTarget(name string) (Target, error) {
+	if t, ok := targets[name]; ok {
+		return t, nil
+	}
+	return nil, errors.New("unknown target architecture: %s", name)
+}
+
+// Returns every registered target, sorted by name.
+func Targets() []Target {
+	out := make([]Target, 0, len(targets))
+	for _, t := range targets {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name() < out[j].Name()
+	})
+	return out
+}
+
+// Configures the programming model (register file size and argument limit)
+// to match a target. Must be called before Flatten.
+func UseTarget(t Target) error {
+	if t.ArgCount() > t.RegCount() {
+		return errors.New(
+			"target %s allows more arguments (%d) than it has registers (%d)",
+			t.Name(), t.ArgCount(), t.RegCount(),
+		)
+	}
+	MaxRegCount = t.RegCount()
+	MaxArgCount = t.ArgCount()
+	return nil
+}
@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Hardware registers backing imp registers 0..7. The callee-saved registers
+// are used so that x0-x8 keep their usual roles for the exit system call. x9
+// is a scratch register for immediates.
+var arm64Regs = [...]string{
+	"x19", "x20", "x21", "x22", "x23", "x24", "x25", "x26",
+}
+
+func init() {
+	Register(target{
+		name:  "arm64",
+		regs:  len(arm64Regs),
+		args:  6,
+		lower: lowerArm64,
+	})
+}
+
+// Lowers psuedo-instructions to AArch64 GNU assembler for Linux. Execution
+// begins at _start with the first psuedo-instruction and the process exits
+// with register 0 as its status once control reaches the end of the program.
+//
+// bl leaves the return address in the link register, so the caller saves x30
+// on the stack around each call so that recursion through rec works. sp must
+// stay 16-byte aligned, so every stack slot (including those of PUSH_R and
+// POP_R) is 16 bytes wide.
+func lowerArm64(psuedo []Ins) ([]byte, error) {
+	var b strings.Builder
+
+	b.WriteString("\t.text\n")
+	b.WriteString("\t.globl _start\n")
+	b.WriteString("_start:\n")
+
+	for i, ins := range psuedo {
+		b.WriteString(fmt.Sprintf(".L%d:\t\t// %s\n", i, ins))
+		asm, err := arm64Ins(ins)
+		if err != nil {
+			return nil, errors.New("ins %d (%s): %s", i, ins, err)
+		}
+		for _, line := range asm {
+			b.WriteString("\t" + line + "\n")
+		}
+	}
+
+	// Exit with register 0 as the status.
+	b.WriteString(fmt.Sprintf(".L%d:\n", len(psuedo)))
+	b.WriteString(fmt.Sprintf("\tmov x0, %s\n", arm64Regs[0]))
+	b.WriteString("\tmov x8, #93\n")
+	b.WriteString("\tsvc #0\n")
+
+	return []byte(b.String()), nil
+}
+
+// Returns the assembly lines implementing a single psuedo-instruction.
+func arm64Ins(ins Ins) ([]string, error) {
+	switch ins.Op {
+	case MoveI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		return arm64Mov(arm64Regs[r], n), nil
+	case MoveR:
+		src, dst, err := regReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("mov %s, %s", arm64Regs[dst], arm64Regs[src])}, nil
+	case AddR, SubR:
+		src, dst, err := regReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		op := map[Opcode]string{AddR: "add", SubR: "sub"}[ins.Op]
+		return []string{
+			fmt.Sprintf("%s %s, %s, %s", op, arm64Regs[dst], arm64Regs[dst], arm64Regs[src]),
+		}, nil
+	case AddI, SubI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		dst := arm64Regs[r]
+		op, neg := "add", "sub"
+		if ins.Op == SubI {
+			op, neg = neg, op
+		}
+		switch {
+		case fitsUint12(n):
+			return []string{fmt.Sprintf("%s %s, %s, #%d", op, dst, dst, n)}, nil
+		case fitsUint12(-n):
+			return []string{fmt.Sprintf("%s %s, %s, #%d", neg, dst, dst, -n)}, nil
+		}
+		return append(
+			arm64Mov("x9", n),
+			fmt.Sprintf("%s %s, %s, x9", op, dst, dst),
+		), nil
+	case BneI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := insAddr(ins, 2)
+		if err != nil {
+			return nil, err
+		}
+		var cmp []string
+		switch {
+		case fitsUint12(n):
+			cmp = []string{fmt.Sprintf("cmp %s, #%d", arm64Regs[r], n)}
+		case fitsUint12(-n):
+			cmp = []string{fmt.Sprintf("cmn %s, #%d", arm64Regs[r], -n)}
+		default:
+			cmp = append(arm64Mov("x9", n), fmt.Sprintf("cmp %s, x9", arm64Regs[r]))
+		}
+		return append(cmp, fmt.Sprintf("b.ne .L%d", addr)), nil
+	case BneR:
+		r0, r1, err := regReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := insAddr(ins, 2)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("cmp %s, %s", arm64Regs[r0], arm64Regs[r1]),
+			fmt.Sprintf("b.ne .L%d", addr),
+		}, nil
+	case CallI:
+		addr, err := insAddr(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			"str x30, [sp, #-16]!",
+			fmt.Sprintf("bl .L%d", addr),
+			"ldr x30, [sp], #16",
+		}, nil
+	case JumpI, TailI:
+		// The return address stays in x30 for tail calls.
+		addr, err := insAddr(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("b .L%d", addr)}, nil
+	case Ret:
+		return []string{"ret"}, nil
+	case PushI:
+		n, err := insNum(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return append(arm64Mov("x9", n), "str x9, [sp, #-16]!"), nil
+	case PushR:
+		r, err := insReg(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("str %s, [sp, #-16]!", arm64Regs[r])}, nil
+	case PopR:
+		r, err := insReg(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("ldr %s, [sp], #16", arm64Regs[r])}, nil
+	}
+	return nil, errors.Unsupported("%s instructions", ins.Op)
+}
+
+// Materializes a 64-bit immediate with movz followed by a movk for each
+// remaining non-zero halfword.
+func arm64Mov(dst string, n Num) []string {
+	u := uint64(n)
+	out := []string{fmt.Sprintf("movz %s, #%d", dst, u&0xffff)}
+	for shift := uint(16); shift < 64; shift += 16 {
+		if half := u >> shift & 0xffff; half != 0 {
+			out = append(out, fmt.Sprintf("movk %s, #%d, lsl #%d", dst, half, shift))
+		}
+	}
+	return out
+}
+
+func fitsUint12(n Num) bool {
+	return n >= 0 && n < 4096
+}
@@ -8,6 +8,13 @@ type genFn func(*gen, ...Psuedo) (int, error)
 
 var builtins map[string]genFn
 
+// Index of the argument each builtin writes to, if any.
+var builtinDsts = map[string]int{
+	"add": 1,
+	"sub": 1,
+	"mov": 1,
+}
+
 func init() {
 	builtins = map[string]genFn{
 		"add": (*gen).add,
@@ -24,6 +31,7 @@ func (g *gen) rec(args ...Psuedo) (int, error) {
 	}
 
 	var n int
+	skip := g.newLabel()
 	if len(args) == 2 {
 		right, ok := args[1].(Reg)
 		if !ok {
@@ -32,29 +40,40 @@ func (g *gen) rec(args ...Psuedo) (int, error) {
 		switch left := args[0].(type) {
 		case Reg:
 			n = g.emit(Ins{
-				Name: "BNE_R",
-				Args: []Psuedo{ left, right, g.here()+2 },
+				Op:   BneR,
+				Args: []Psuedo{ left, right, skip },
 			})
 		case Num:
 			n = g.emit(Ins{
-				Name: "BNE_I",
-				Args: []Psuedo{ left, right, g.here()+2 },
+				Op:   BneI,
+				Args: []Psuedo{ left, right, skip },
 			})
 		default:
 			return 0, errors.New("left argument of ret must be a register or number")
 		}
 	}
-	n += g.emit(Ins{
-		Name: "CALL_I",
-		Args: []Psuedo{ g.context().Addr },
-	})
+	if g.tail {
+		// Nothing but a ret follows, so the procedure can start over
+		// in its own frame, which keeps loops from growing the stack.
+		n += g.emit(Ins{
+			Op:   JumpI,
+			Args: []Psuedo{ g.localScope().body },
+		})
+	} else {
+		n += g.emit(Ins{
+			Op:   CallI,
+			Args: []Psuedo{ g.context().Addr },
+		})
+	}
+	n += g.mark(skip)
 
 	return n, nil
 }
 
 func (g *gen) ret(args ...Psuedo) (int, error) {
 	if len(args) == 0 {
-		return g.emit(Ins{ Name: "RET" }), nil
+		n := g.restoreLocals()
+		return n + g.emit(Ins{ Op: Ret }), nil
 	}
 	if len(args) != 2 {
 		return 0, errors.New("ret expects either 0 or 2 arguments")
@@ -66,21 +85,24 @@ func (g *gen) ret(args ...Psuedo) (int, error) {
 	}
 
 	var n int
+	skip := g.newLabel()
 	switch left := args[0].(type) {
 	case Reg:
 		n = g.emit(Ins{
-			Name: "BNE_R",
-			Args: []Psuedo{ left, right, g.here()+2 },
+			Op:   BneR,
+			Args: []Psuedo{ left, right, skip },
 		})
 	case Num:
 		n = g.emit(Ins{
-			Name: "BNE_I",
-			Args: []Psuedo{ left, right, g.here()+2 },
+			Op:   BneI,
+			Args: []Psuedo{ left, right, skip },
 		})
 	default:
 		return 0, errors.New("left argument of ret must be a register or number")
 	}
-	n += g.emit(Ins{ Name: "RET" })
+	n += g.restoreLocals()
+	n += g.emit(Ins{ Op: Ret })
+	n += g.mark(skip)
 	return n, nil
 }
 
@@ -98,12 +120,12 @@ func (g *gen) mov(args ...Psuedo) (int, error) {
 	switch src := args[0].(type) {
 	case Reg:
 		n = g.emit(Ins{
-			Name: "MOVE_R",
+			Op:   MoveR,
 			Args: []Psuedo{ src, dst },
 		})
 	case Num:
 		n = g.emit(Ins{
-			Name: "MOVE_I",
+			Op:   MoveI,
 			Args: []Psuedo{ src, dst },
 		})
 	default:
@@ -126,12 +148,12 @@ func (g *gen) add(args ...Psuedo) (int, error) {
 	switch src := args[0].(type) {
 	case Reg:
 		n = g.emit(Ins{
-			Name: "ADD_R",
+			Op:   AddR,
 			Args: []Psuedo{ src, dst },
 		})
 	case Num:
 		n = g.emit(Ins{
-			Name: "ADD_I",
+			Op:   AddI,
 			Args: []Psuedo{ src, dst },
 		})
 	default:
@@ -154,12 +176,12 @@ func (g *gen) sub(args ...Psuedo) (int, error) {
 	switch src := args[0].(type) {
 	case Reg:
 		n = g.emit(Ins{
-			Name: "SUB_R",
+			Op:   SubR,
 			Args: []Psuedo{ src, dst },
 		})
 	case Num:
 		n = g.emit(Ins{
-			Name: "SUB_I",
+			Op:   SubI,
 			Args: []Psuedo{ src, dst },
 		})
 	default:
@@ -0,0 +1,356 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+	"github.com/ialeinbach/imp/frontend"
+)
+
+// Layout of a bytecode file, after the magic number and version:
+//
+//	registers  uvarint
+//	opcodes    uvarint count, then each name as a string
+//	symbols    uvarint count, then a name string and uvarint address each
+//	code       uvarint count, then each instruction as
+//	             opcode   uvarint index into the opcode table
+//	             operands uvarint count, then a tag byte and a value each
+//	             comment  string
+//
+// Strings are a uvarint length followed by that many bytes. Registers are
+// uvarints and numbers are zigzag varints.
+const (
+	BytecodeMagic   = "IMPB"
+	BytecodeVersion = 1
+
+	// Extension of bytecode files. Writing one with -o selects the bytecode
+	// target.
+	BytecodeExt = ".impb"
+)
+
+// Operand tags. Labels only appear in object files.
+const (
+	bytecodeReg   byte = 'r'
+	bytecodeNum   byte = 'n'
+	bytecodeLabel byte = 'l'
+)
+
+func init() {
+	Register(target{
+		name:  "impb",
+		regs:  8,
+		args:  6,
+		lower: EncodeBytecode,
+	})
+}
+
+// A named procedure address.
+type Symbol struct {
+	Name string
+	Addr Num
+}
+
+// Returns the symbol table of a program: the address of every procedure
+// declared in it, named after its decl.
+func Symbols(psuedo []Ins) []Symbol {
+	var syms []Symbol
+	for addr, ins := range psuedo {
+		if ins.Op != JumpI || !strings.HasPrefix(ins.Comment, declComment+" ") {
+			continue
+		}
+		name := strings.TrimPrefix(ins.Comment, declComment+" ")
+		syms = append(syms, Symbol{Name: name, Addr: Num(addr + 1)})
+	}
+	return syms
+}
+
+// Returns true if data starts with the bytecode magic number.
+func IsBytecode(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(BytecodeMagic))
+}
+
+// Returns the psuedo-instructions of a program given as source, bytecode or,
+// for files with the PsuedoExt extension, a listing.
+func Load(filename string, data []byte) ([]Ins, error) {
+	if filepath.Ext(filename) == PsuedoExt {
+		return ParsePsuedo(string(data))
+	}
+	if IsBytecode(data) {
+		psuedo, _, err := DecodeBytecode(data)
+		return psuedo, err
+	}
+	if IsObject(data) {
+		return nil, errors.New("%s is an object file, which must be linked with impld", filename)
+	}
+	ast, err := frontend.ParseFile(filename, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return Flatten(ast)
+}
+
+// Encodes psuedo-instructions as bytecode.
+func EncodeBytecode(psuedo []Ins) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(BytecodeMagic)
+	b.WriteByte(BytecodeVersion)
+	writeUvarint(&b, uint64(MaxRegCount))
+	index := writeOpcodes(&b, psuedo)
+
+	syms := Symbols(psuedo)
+	writeUvarint(&b, uint64(len(syms)))
+	for _, sym := range syms {
+		writeString(&b, sym.Name)
+		writeUvarint(&b, uint64(sym.Addr))
+	}
+
+	if err := writeCode(&b, psuedo, index); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Writes the opcode table of some code and returns the index of each opcode
+// in it.
+func writeOpcodes(b *bytes.Buffer, code []Ins) map[Opcode]int {
+	var (
+		ops   []Opcode
+		index = make(map[Opcode]int)
+	)
+	for _, ins := range code {
+		if _, ok := index[ins.Op]; !ok {
+			index[ins.Op] = len(ops)
+			ops = append(ops, ins.Op)
+		}
+	}
+	writeUvarint(b, uint64(len(ops)))
+	for _, op := range ops {
+		writeString(b, op.String())
+	}
+	return index
+}
+
+// Writes code, given the index of each opcode in the opcode table.
+func writeCode(b *bytes.Buffer, code []Ins, index map[Opcode]int) error {
+	writeUvarint(b, uint64(len(code)))
+	for i, ins := range code {
+		writeUvarint(b, uint64(index[ins.Op]))
+		writeUvarint(b, uint64(len(ins.Args)))
+		for _, arg := range ins.Args {
+			switch arg := arg.(type) {
+			case Reg:
+				if arg < 0 {
+					return errors.New("ins %d (%s): register %d out of range", i, ins, arg)
+				}
+				b.WriteByte(bytecodeReg)
+				writeUvarint(b, uint64(arg))
+			case Num:
+				b.WriteByte(bytecodeNum)
+				writeVarint(b, int64(arg))
+			case Label:
+				if arg < 0 {
+					return errors.New("ins %d (%s): label %d out of range", i, ins, arg)
+				}
+				b.WriteByte(bytecodeLabel)
+				writeUvarint(b, uint64(arg))
+			default:
+				return errors.New("ins %d (%s): can't encode %s operands", i, ins, arg.Type())
+			}
+		}
+		writeString(b, ins.Comment)
+	}
+	return nil
+}
+
+// Decodes bytecode into psuedo-instructions and their symbol table. The
+// program must fit in MaxRegCount registers.
+func DecodeBytecode(data []byte) ([]Ins, []Symbol, error) {
+	if !IsBytecode(data) {
+		return nil, nil, errors.New("bytecode: bad magic number")
+	}
+	r := &bytecodeReader{r: bytes.NewReader(data[len(BytecodeMagic):])}
+
+	if v := r.byte(); r.err == nil && v != BytecodeVersion {
+		return nil, nil, errors.New("bytecode: unsupported version %d", v)
+	}
+	regs := r.uvarint()
+	if r.err == nil && regs > uint64(MaxRegCount) {
+		return nil, nil, errors.New(
+			"bytecode: program needs %d registers but the target has %d", regs, MaxRegCount,
+		)
+	}
+
+	ops, err := r.opcodes()
+	if err != nil {
+		return nil, nil, errors.New("bytecode: %s", err)
+	}
+
+	syms := make([]Symbol, r.count())
+	for i := range syms {
+		syms[i] = Symbol{Name: r.string(), Addr: Num(r.uvarint())}
+	}
+
+	psuedo, err := r.code(ops, regs)
+	if err != nil {
+		return nil, nil, errors.New("bytecode: %s", err)
+	}
+	if r.r.Len() > 0 {
+		return nil, nil, errors.New("bytecode: trailing data")
+	}
+
+	for _, sym := range syms {
+		if sym.Addr < 0 || int(sym.Addr) > len(psuedo) {
+			return nil, nil, errors.New("bytecode: symbol %s: address %d out of range", sym.Name, sym.Addr)
+		}
+	}
+	if err := Check(psuedo); err != nil {
+		return nil, nil, errors.New("bytecode: %s", err)
+	}
+	return psuedo, syms, nil
+}
+
+// Reads the fields of a bytecode file. After the first error, every read
+// returns a zero value and err holds the error.
+type bytecodeReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+// Reads an opcode table.
+func (r *bytecodeReader) opcodes() ([]Opcode, error) {
+	ops := make([]Opcode, r.count())
+	for i := range ops {
+		name := r.string()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if name == Mark.String() {
+			ops[i] = Mark
+			continue
+		}
+		op, err := LookupOpcode(name)
+		if err != nil {
+			return nil, err
+		}
+		ops[i] = op
+	}
+	return ops, r.err
+}
+
+// Reads code using an opcode table. Registers must be less than regs.
+func (r *bytecodeReader) code(ops []Opcode, regs uint64) ([]Ins, error) {
+	code := make([]Ins, r.count())
+	for i := range code {
+		op := r.uvarint()
+		if r.err == nil && op >= uint64(len(ops)) {
+			return nil, errors.New("ins %d: opcode %d out of range", i, op)
+		}
+		args := make([]Psuedo, r.count())
+		for k := range args {
+			switch tag := r.byte(); tag {
+			case bytecodeReg:
+				reg := r.uvarint()
+				if r.err == nil && reg >= regs {
+					return nil, errors.New("ins %d: register %d out of range", i, reg)
+				}
+				args[k] = Reg(reg)
+			case bytecodeNum:
+				args[k] = Num(r.varint())
+			case bytecodeLabel:
+				args[k] = Label(r.uvarint())
+			default:
+				if r.err == nil {
+					return nil, errors.New("ins %d: bad operand tag %q", i, tag)
+				}
+			}
+		}
+		comment := r.string()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if len(args) == 0 {
+			args = nil
+		}
+		code[i] = Ins{Op: ops[op], Args: args, Comment: comment}
+	}
+	return code, r.err
+}
+
+func (r *bytecodeReader) fail(err error) {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *bytecodeReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	c, err := r.r.ReadByte()
+	r.fail(err)
+	return c
+}
+
+func (r *bytecodeReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	n, err := binary.ReadUvarint(r.r)
+	r.fail(err)
+	return n
+}
+
+func (r *bytecodeReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	n, err := binary.ReadVarint(r.r)
+	r.fail(err)
+	return n
+}
+
+// Reads the length of a table or string. Every entry takes at least a byte, so
+// neither can be longer than the rest of the file.
+func (r *bytecodeReader) count() int {
+	n := r.uvarint()
+	if r.err == nil && n > uint64(r.r.Len()) {
+		r.fail(errors.New("length %d exceeds the remaining %d bytes", n, r.r.Len()))
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (r *bytecodeReader) string() string {
+	n := r.count()
+	if r.err != nil || n == 0 {
+		return ""
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(r.r, b)
+	r.fail(err)
+	return string(b)
+}
+
+func writeUvarint(b *bytes.Buffer, n uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	b.Write(buf[:binary.PutUvarint(buf[:], n)])
+}
+
+func writeVarint(b *bytes.Buffer, n int64) {
+	var buf [binary.MaxVarintLen64]byte
+	b.Write(buf[:binary.PutVarint(buf[:], n)])
+}
+
+func writeString(b *bytes.Buffer, s string) {
+	writeUvarint(b, uint64(len(s)))
+	b.WriteString(s)
+}
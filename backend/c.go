@@ -0,0 +1,245 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Slots in the psuedo stack of generated C programs.
+const cStackSize = 1 << 16
+
+func init() {
+	Register(target{
+		name:  "c",
+		regs:  8,
+		args:  6,
+		lower: lowerC,
+	})
+}
+
+const cPrelude = `/* Generated by imp. */
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+#define STACK_SIZE %d
+
+/* Register file. Registers are unsigned so that arithmetic wraps. */
+static uint64_t r[%d];
+
+/* Psuedo stack for PUSH_R and POP_R. Return addresses live on the C stack. */
+static uint64_t stack[STACK_SIZE];
+static size_t sp;
+
+static inline void push(uint64_t v)
+{
+	if (sp == STACK_SIZE) {
+		fputs("imp: stack overflow\n", stderr);
+		exit(1);
+	}
+	stack[sp++] = v;
+}
+
+static inline uint64_t pop(void)
+{
+	if (sp == 0) {
+		fputs("imp: cannot pop empty stack\n", stderr);
+		exit(1);
+	}
+	return stack[--sp];
+}
+`
+
+// Lowers psuedo-instructions to a portable C program. Each procedure becomes
+// a C function and the top-level code becomes main, which returns register 0.
+// Jumps within a procedure become gotos.
+func lowerC(psuedo []Ins) ([]byte, error) {
+	procs, err := Procs(psuedo)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(cPrelude, cStackSize, MaxRegCount))
+
+	if len(procs) > 1 {
+		b.WriteString("\n")
+		for _, p := range procs[1:] {
+			b.WriteString(fmt.Sprintf("static void proc%d(void);\n", p.Addr))
+		}
+	}
+
+	for i, p := range procs {
+		b.WriteString("\n")
+		if i == 0 {
+			b.WriteString("int main(void)\n{\n")
+		} else {
+			b.WriteString(fmt.Sprintf("static void proc%d(void)\n{\n", p.Addr))
+		}
+
+		ret := "return;"
+		if i == 0 {
+			ret = "return (int)r[0];"
+		}
+
+		body := p.Body(procs)
+		targets, err := jumpTargets(psuedo, p, body)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range body {
+			ins := psuedo[addr]
+			if targets[addr] {
+				b.WriteString(fmt.Sprintf("L%d:\n", addr))
+			}
+			stmt, err := cIns(ins, ret)
+			if err != nil {
+				return nil, errors.New("ins %d (%s): %s", addr, ins, err)
+			}
+			b.WriteString(fmt.Sprintf("\t%s /* %s */\n", stmt, ins))
+		}
+		if targets[p.End] {
+			b.WriteString(fmt.Sprintf("L%d:\n", p.End))
+		}
+		b.WriteString("\t" + ret + "\n}\n")
+	}
+
+	return []byte(b.String()), nil
+}
+
+// Returns the set of addresses jumped to from the body of a procedure. Calls
+// aren't jumps. Every target must be in the body or at its end.
+func jumpTargets(psuedo []Ins, p Proc, body []Num) (map[Num]bool, error) {
+	valid := map[Num]bool{p.End: true}
+	for _, addr := range body {
+		valid[addr] = true
+	}
+
+	targets := make(map[Num]bool)
+	for _, addr := range body {
+		ins := psuedo[addr]
+		var operand int
+		switch ins.Op {
+		case JumpI:
+			operand = 0
+		case BneI, BneR:
+			operand = 2
+		default:
+			continue
+		}
+		to, err := insAddr(ins, operand)
+		if err != nil {
+			return nil, errors.New("ins %d (%s): %s", addr, ins, err)
+		}
+		if !valid[to] {
+			return nil, errors.New("ins %d (%s): jump out of procedure at %d", addr, ins, p.Addr)
+		}
+		targets[to] = true
+	}
+	return targets, nil
+}
+
+// Returns the C statement implementing a single psuedo-instruction. ret is the
+// statement that returns from the enclosing function.
+func cIns(ins Ins, ret string) (string, error) {
+	switch ins.Op {
+	case MoveI:
+		n, d, err := numReg(ins)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("r[%d] = %s;", d, cNum(n)), nil
+	case MoveR:
+		s, d, err := regReg(ins)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("r[%d] = r[%d];", d, s), nil
+	case AddR, SubR:
+		s, d, err := regReg(ins)
+		if err != nil {
+			return "", err
+		}
+		op := map[Opcode]string{AddR: "+=", SubR: "-="}[ins.Op]
+		return fmt.Sprintf("r[%d] %s r[%d];", d, op, s), nil
+	case AddI, SubI:
+		n, d, err := numReg(ins)
+		if err != nil {
+			return "", err
+		}
+		op := map[Opcode]string{AddI: "+=", SubI: "-="}[ins.Op]
+		return fmt.Sprintf("r[%d] %s %s;", d, op, cNum(n)), nil
+	case BneI:
+		n, s, err := numReg(ins)
+		if err != nil {
+			return "", err
+		}
+		to, err := insAddr(ins, 2)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("if (%s != r[%d]) goto L%d;", cNum(n), s, to), nil
+	case BneR:
+		s0, s1, err := regReg(ins)
+		if err != nil {
+			return "", err
+		}
+		to, err := insAddr(ins, 2)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("if (r[%d] != r[%d]) goto L%d;", s0, s1, to), nil
+	case JumpI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("goto L%d;", to), nil
+	case CallI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("proc%d();", to), nil
+	case TailI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("proc%d(); %s", to, ret), nil
+	case Ret:
+		return ret, nil
+	case PushI:
+		n, err := insNum(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("push(%s);", cNum(n)), nil
+	case PushR:
+		s, err := insReg(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("push(r[%d]);", s), nil
+	case PopR:
+		d, err := insReg(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("r[%d] = pop();", d), nil
+	}
+	return "", errors.Unsupported("%s instructions", ins.Op)
+}
+
+// Returns a C expression for a number as a register value. The most negative
+// int64 has no literal of its own in C.
+func cNum(n Num) string {
+	if n == math.MinInt64 {
+		return "(uint64_t)INT64_MIN"
+	}
+	return fmt.Sprintf("(uint64_t)INT64_C(%d)", n)
+}
@@ -0,0 +1,263 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Virtual address the executable is loaded at. The code follows the ELF header
+// and the single program header in the same segment.
+const (
+	elfBase       uint64 = 0x400000
+	elfHeaderSize uint64 = 64
+	elfPhdrSize   uint64 = 56
+)
+
+func init() {
+	Register(target{
+		name:  "amd64-elf",
+		regs:  len(amd64Regs),
+		args:  6,
+		exec:  true,
+		lower: lowerElf,
+	})
+}
+
+// Lowers psuedo-instructions to a statically linked x86-64 Linux executable.
+// The program has the same semantics as the output of the amd64 target, but
+// the machine code is encoded here so no external assembler or linker is
+// needed.
+func lowerElf(psuedo []Ins) ([]byte, error) {
+	code, err := encodeAmd64(psuedo)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		b     bytes.Buffer
+		entry = elfBase + elfHeaderSize + elfPhdrSize
+		size  = elfHeaderSize + elfPhdrSize + uint64(len(code))
+	)
+
+	// ELF header.
+	b.Write([]byte{0x7f, 'E', 'L', 'F'})
+	b.Write([]byte{
+		2, // ELFCLASS64
+		1, // ELFDATA2LSB
+		1, // EV_CURRENT
+		0, // ELFOSABI_SYSV
+	})
+	b.Write(make([]byte, 8)) // padding
+	binary.Write(&b, binary.LittleEndian, struct {
+		Type      uint16
+		Machine   uint16
+		Version   uint32
+		Entry     uint64
+		Phoff     uint64
+		Shoff     uint64
+		Flags     uint32
+		Ehsize    uint16
+		Phentsize uint16
+		Phnum     uint16
+		Shentsize uint16
+		Shnum     uint16
+		Shstrndx  uint16
+	}{
+		Type:      2,    // ET_EXEC
+		Machine:   0x3e, // EM_X86_64
+		Version:   1,
+		Entry:     entry,
+		Phoff:     elfHeaderSize,
+		Ehsize:    uint16(elfHeaderSize),
+		Phentsize: uint16(elfPhdrSize),
+		Phnum:     1,
+	})
+
+	// Program header loading the whole file as one read-execute segment.
+	binary.Write(&b, binary.LittleEndian, struct {
+		Type   uint32
+		Flags  uint32
+		Offset uint64
+		Vaddr  uint64
+		Paddr  uint64
+		Filesz uint64
+		Memsz  uint64
+		Align  uint64
+	}{
+		Type:   1,     // PT_LOAD
+		Flags:  4 | 1, // PF_R | PF_X
+		Vaddr:  elfBase,
+		Paddr:  elfBase,
+		Filesz: size,
+		Memsz:  size,
+		Align:  0x1000,
+	})
+
+	b.Write(code)
+	return b.Bytes(), nil
+}
+
+// A rel32 field that must be patched once instruction offsets are known.
+type amd64Fixup struct {
+	at     int // offset of the rel32 field in the code
+	target Num // psuedo-instruction the field refers to
+}
+
+// Encodes psuedo-instructions as x86-64 machine code followed by an exit
+// syscall. Jump and call targets are absolute psuedo-instruction addresses,
+// which are resolved into displacements relative to the end of the encoded
+// instruction.
+func encodeAmd64(psuedo []Ins) ([]byte, error) {
+	var (
+		code    []byte
+		offsets = make([]int, len(psuedo)+1)
+		fixups  []amd64Fixup
+	)
+
+	for i, ins := range psuedo {
+		offsets[i] = len(code)
+		enc, fixup, err := encodeAmd64Ins(ins)
+		if err != nil {
+			return nil, errors.New("ins %d (%s): %s", i, ins, err)
+		}
+		if fixup != nil {
+			if fixup.target < 0 || int(fixup.target) > len(psuedo) {
+				return nil, errors.New("ins %d (%s): address out of range", i, ins)
+			}
+			fixup.at += len(code)
+			fixups = append(fixups, *fixup)
+		}
+		code = append(code, enc...)
+	}
+
+	// Exit with register 0 as the status.
+	offsets[len(psuedo)] = len(code)
+	code = append(code,
+		0x4c, 0x89, 0xc7, // mov %r8, %rdi
+		0xb8, 60, 0, 0, 0, // mov $60, %eax
+		0x0f, 0x05, // syscall
+	)
+
+	for _, f := range fixups {
+		rel := offsets[f.target] - (f.at + 4)
+		binary.LittleEndian.PutUint32(code[f.at:], uint32(int32(rel)))
+	}
+
+	return code, nil
+}
+
+// Encodes a single psuedo-instruction. If the encoding contains a rel32 field,
+// the returned fixup records its offset within the encoding.
+func encodeAmd64Ins(ins Ins) ([]byte, *amd64Fixup, error) {
+	switch ins.Op {
+	case MoveI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fitsInt32(n) {
+			// mov $imm32, r/m64
+			return append([]byte{0x49, 0xc7, modrm(0, r)}, imm32(n)...), nil, nil
+		}
+		// movabs $imm64, r64
+		return append([]byte{0x49, 0xb8 + byte(r)}, imm64(n)...), nil, nil
+	case MoveR, AddR, SubR:
+		src, dst, err := regReg(ins)
+		if err != nil {
+			return nil, nil, err
+		}
+		op := map[Opcode]byte{MoveR: 0x89, AddR: 0x01, SubR: 0x29}[ins.Op]
+		return []byte{0x4d, op, modrm(byte(src), dst)}, nil, nil
+	case AddI, SubI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, nil, err
+		}
+		digit := map[Opcode]byte{AddI: 0, SubI: 5}[ins.Op]
+		return amd64ImmOp(digit, n, r), nil, nil
+	case BneI, BneR:
+		var cmp []byte
+		if ins.Op == BneI {
+			n, r, err := numReg(ins)
+			if err != nil {
+				return nil, nil, err
+			}
+			cmp = amd64ImmOp(7, n, r)
+		} else {
+			r0, r1, err := regReg(ins)
+			if err != nil {
+				return nil, nil, err
+			}
+			cmp = []byte{0x4d, 0x39, modrm(byte(r0), r1)}
+		}
+		addr, err := insAddr(ins, 2)
+		if err != nil {
+			return nil, nil, err
+		}
+		// jne rel32
+		enc := append(cmp, 0x0f, 0x85, 0, 0, 0, 0)
+		return enc, &amd64Fixup{at: len(enc) - 4, target: addr}, nil
+	case CallI, JumpI, TailI:
+		addr, err := insAddr(ins, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		// call rel32 or jmp rel32
+		op := map[Opcode]byte{CallI: 0xe8, JumpI: 0xe9, TailI: 0xe9}[ins.Op]
+		return []byte{op, 0, 0, 0, 0}, &amd64Fixup{at: 1, target: addr}, nil
+	case Ret:
+		return []byte{0xc3}, nil, nil
+	case PushI:
+		n, err := insNum(ins, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fitsInt32(n) {
+			// push $imm32
+			return append([]byte{0x68}, imm32(n)...), nil, nil
+		}
+		// movabs $imm64, %rax; push %rax
+		return append(append([]byte{0x48, 0xb8}, imm64(n)...), 0x50), nil, nil
+	case PushR, PopR:
+		r, err := insReg(ins, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		op := map[Opcode]byte{PushR: 0x50, PopR: 0x58}[ins.Op]
+		return []byte{0x41, op + byte(r)}, nil, nil
+	}
+	return nil, nil, errors.Unsupported("%s instructions", ins.Op)
+}
+
+// Encodes an 0x81 group instruction (add, sub or cmp selected by digit) with
+// an immediate operand, going through %rax when the immediate can't be
+// sign-extended from 32 bits.
+func amd64ImmOp(digit byte, n Num, r Reg) []byte {
+	if fitsInt32(n) {
+		return append([]byte{0x49, 0x81, modrm(digit, r)}, imm32(n)...)
+	}
+	op := map[byte]byte{0: 0x01, 5: 0x29, 7: 0x39}[digit]
+	enc := append([]byte{0x48, 0xb8}, imm64(n)...) // movabs $imm64, %rax
+	return append(enc, 0x49, op, modrm(0, r))      // op %rax, r
+}
+
+// Returns a register-direct ModRM byte. Imp registers are backed by %r8-%r15
+// (see amd64Regs), so the extension bit of rm is always carried by the REX
+// prefix.
+func modrm(reg byte, rm Reg) byte {
+	return 0xc0 | (reg&7)<<3 | byte(rm)&7
+}
+
+func imm32(n Num) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(int32(n)))
+	return b
+}
+
+func imm64(n Num) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(n))
+	return b
+}
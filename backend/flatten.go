@@ -1,9 +1,12 @@
 package backend
 
 import (
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/ialeinbach/imp/errors"
 	"github.com/ialeinbach/imp/frontend"
-	"strconv"
 )
 
 // Returns psuedo-instructions generated from a program.
@@ -12,21 +15,31 @@ func Flatten(prog []frontend.Stmt) ([]Ins, error) {
 		scopes: []*scope{globalScope()},
 		code:   []Ins{},
 	}
+	g.start()
 	_, err := g.prog(prog)
 	if err != nil {
 		return nil, err
 	}
-	errors.DebugBackend(1, true, DumpPsuedo(g.code))
+	psuedo, err := Resolve(g.code)
+	if err != nil {
+		return nil, err
+	}
+	errors.DebugBackend(1, true, DumpPsuedo(psuedo))
 	errors.DebugBackend(1, false, "\n\n")
-	return g.code, nil
+	return psuedo, nil
 }
 
 // Generates psuedo-instructions for a program.
 func (g *gen) prog(prog []frontend.Stmt) (n int, err error) {
+	if err = g.declare(prog); err != nil {
+		return
+	}
+
 	var i int
-	for _, stmt := range prog {
+	for k, stmt := range prog {
 		switch stmt := stmt.(type) {
 		case frontend.Call:
+			g.tail = g.returns(prog[k+1:])
 			if i, err = g.call(stmt); err != nil {
 				err = errors.Wrap(err, stmt)
 				return
@@ -36,12 +49,56 @@ func (g *gen) prog(prog []frontend.Stmt) (n int, err error) {
 				err = errors.Wrap(err, stmt)
 				return
 			}
+		case frontend.Const:
+			if i, err = g.constant(stmt); err != nil {
+				err = errors.Wrap(err, stmt)
+				return
+			}
+		case frontend.RegDecl:
+			if i, err = g.regDecl(stmt); err != nil {
+				err = errors.Wrap(err, stmt)
+				return
+			}
+		case frontend.Local:
+			// Locals are allocated by g.locals when the decl is entered.
+			if len(g.scopes) == 1 {
+				err = errors.Wrap(errors.New("locals can only be declared in procedures"), stmt)
+				return
+			}
+		case frontend.Use:
+			// Use statements are resolved by frontend.ParseFile.
+			err = errors.Wrap(errors.New("use statement was never loaded"), stmt)
+			return
 		}
 		n += i
 	}
 	return
 }
 
+// Returns true if the next code after a call is an unconditional ret from the
+// current procedure, so that the call is in tail position. Statements that
+// take no instructions are skipped.
+func (g *gen) returns(rest []frontend.Stmt) bool {
+	if len(g.scopes) == 1 {
+		return false
+	}
+	for _, stmt := range rest {
+		switch stmt := stmt.(type) {
+		case frontend.Const, frontend.RegDecl, frontend.Local:
+			continue
+		case frontend.Call:
+			if stmt.String() != "ret" || len(stmt.Args) != 0 {
+				return false
+			}
+			// A procedure named ret hides the builtin.
+			_, err := g.lookup(stmt.Cmd)
+			return err != nil
+		}
+		return false
+	}
+	return false
+}
+
 // Generates psuedo-instructions for a call.
 func (g *gen) call(call frontend.Call) (int, error) {
 	// Look for Cmd in surrounding scopes.
@@ -51,6 +108,13 @@ func (g *gen) call(call frontend.Call) (int, error) {
 		if err != nil {
 			return 0, err
 		}
+		for i, arg := range args {
+			if _, ok := arg.(Reg); ok && cmd.writes(i) {
+				if err := g.writable(call.Args[i], arg); err != nil {
+					return 0, err
+				}
+			}
+		}
 		return g.procCall(cmd, args), nil
 	}
 
@@ -60,6 +124,11 @@ func (g *gen) call(call frontend.Call) (int, error) {
 		if err != nil {
 			return 0, err
 		}
+		if i, ok := builtinDsts[call.String()]; ok && i < len(args) {
+			if err := g.writable(call.Args[i], args[i]); err != nil {
+				return 0, err
+			}
+		}
 		n, err := fn(g, args...)
 		if err != nil {
 			return 0, err
@@ -67,118 +136,339 @@ func (g *gen) call(call frontend.Call) (int, error) {
 		return n, err
 	}
 
+	// Leave Cmd for the linker to find.
+	if g.imports != nil {
+		return g.importCall(call)
+	}
+
 	return 0, errors.Undefined(call)
 }
 
-// Generates psuedo-instructions for a declaration.
-func (g *gen) decl(decl frontend.Decl) (int, error) {
-	// Create parameter template for type checking call arguments.
+// Returns an error if an argument is a num param or an in param, which are
+// read-only.
+func (g *gen) writable(arg frontend.Alias, ps Psuedo) error {
+	reg, ok := ps.(Reg)
+	if !ok {
+		return nil
+	}
+	switch arg.(type) {
+	case frontend.NumAlias:
+		return errors.New("num param %s is read-only", arg)
+	case frontend.RegAlias:
+		if g.localScope().inputs[reg] {
+			return errors.New("%s is an in param, so it's read-only", arg)
+		}
+	}
+	return nil
+}
+
+// Generates psuedo-instructions for a call to a procedure in another object.
+func (g *gen) importCall(call frontend.Call) (int, error) {
+	if len(call.Args) > MaxArgCount {
+		return 0, errors.New(
+			"procedures can have at most %d parameters", MaxArgCount,
+		)
+	}
+	args, err := g.typecheck(call.Args, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var sig strings.Builder
+	for i, arg := range call.Args {
+		if _, ok := arg.(frontend.RegAlias); !ok {
+			sig.WriteByte(sigNum)
+		} else if g.writable(arg, args[i]) != nil {
+			sig.WriteByte(sigIn)
+		} else {
+			sig.WriteByte(sigReg)
+		}
+	}
+
+	imp, ok := g.imports[call.String()]
+	if !ok {
+		imp = &Import{
+			Name:  call.String(),
+			Label: g.newLabel(),
+		}
+		g.imports[imp.Name] = imp
+	}
+	seen := false
+	for _, c := range imp.Calls {
+		seen = seen || c == sig.String()
+	}
+	if !seen {
+		imp.Calls = append(imp.Calls, sig.String())
+	}
+
+	return g.procCall(Cmd{ Addr: imp.Label }, args), nil
+}
+
+// Comment prefix marking the JUMP_I over the body of a decl, which names the
+// procedure for symbol tables.
+const declComment = "decl"
+
+// Declares the procedures of a scope before generating any code for it, so
+// that calls can refer to procedures declared later in the scope and
+// procedures can call each other. Their addresses are labels marked by decl.
+func (g *gen) declare(prog []frontend.Stmt) error {
+	for _, stmt := range prog {
+		decl, ok := stmt.(frontend.Decl)
+		if !ok {
+			continue
+		}
+		cmd, err := g.declCmd(decl)
+		if err != nil {
+			return errors.Wrap(err, decl)
+		}
+		if err := g.localScope().define(decl.Cmd, cmd); err != nil {
+			return errors.Wrap(err, decl)
+		}
+	}
+	return nil
+}
+
+// Returns the entry of a declaration, with a parameter template for type
+// checking call arguments.
+func (g *gen) declCmd(decl frontend.Decl) (Cmd, error) {
+	if len(decl.Params) > MaxArgCount {
+		return Cmd{}, errors.New(
+			"procedures can have at most %d parameters", MaxArgCount,
+		)
+	}
+
 	params := make([]Psuedo, len(decl.Params))
+	modes := make([]frontend.Mode, len(decl.Params))
 	for i, param := range decl.Params {
+		mode := frontend.NoMode
+		if i < len(decl.Modes) {
+			mode = decl.Modes[i]
+		}
 		switch param := param.(type) {
 		case frontend.RegAlias:
 			params[i] = Reg(0)
+			modes[i] = mode
+			if mode == frontend.NoMode {
+				modes[i] = frontend.InOut
+			}
 		case frontend.NumAlias:
-			if _, err := strconv.ParseInt(param.String(), 0, 0); err == nil {
-				return 0, errors.New("params can't be number constants")
+			if mode != frontend.NoMode && mode != frontend.In {
+				return Cmd{}, errors.New("num param %s is read-only, so it can't be an %s param", param, mode)
 			}
 			params[i] = Num(0)
+			modes[i] = frontend.In
+		case frontend.NumLit:
+			return Cmd{}, errors.New("params can't be number constants")
 		default:
-			return 0, errors.Unsupported("%s parameters", param.Type())
+			return Cmd{}, errors.Unsupported("%s parameters", param.Type())
 		}
 	}
 
-	// Addr to be backfilled after decl body size known.
-	n := g.emit(Ins{
-		Name: "JUMP_I",
-	})
-
-	// Create entry and add to current scope.
-	cmd := Cmd{
-		Addr:   g.here(),
+	return Cmd{
+		Addr:   g.newLabel(),
 		Params: params,
-	}
-	g.define(decl.String(), cmd)
+		Modes:  modes,
+	}, nil
+}
+
+// Generates psuedo-instructions for a declaration, whose entry is already in
+// the current scope.
+func (g *gen) decl(decl frontend.Decl) (int, error) {
+	cmd := g.localScope().cmds[decl.String()] // ensured by declare()
+
+	// Jump over the declaration body, which ends at the end label.
+	end := g.newLabel()
+	n := g.emit(Ins{
+		Op:   JumpI,
+		Args: []Psuedo{ end },
+	}.WithComment("%s %s", declComment, decl))
+	n += g.mark(cmd.Addr)
 
 	// Create inner scope for declaration body.
-	err := g.enterScope(decl)
+	err := g.enterScope(decl, cmd)
 	if err != nil {
 		return 0, err
 	}
-	g.define(g.localScope().name, cmd)
 	defer g.exitScope()
 
+	// Save the registers of locals.
+	i, err := g.locals(decl)
+	if err != nil {
+		return 0, err
+	}
+	n += i
+	g.localScope().body = g.newLabel()
+	n += g.mark(g.localScope().body)
+
 	// Generate psuedo-instructions for declaration body.
-	i, err := g.prog(decl.Body)
+	i, err = g.prog(decl.Body)
 	if err != nil {
 		return 0, err
 	}
 	n += i
+	n += g.mark(end)
 
-	// Backfill jump over declaration body.
-	g.code[len(g.code)-1-i].Args = []Psuedo{ g.here() }
+	return n, nil
+}
 
+// Allocates registers for the locals declared in a decl body, after those of
+// its params, and saves them until the procedure returns.
+func (g *gen) locals(decl frontend.Decl) (n int, err error) {
+	local := g.localScope()
+	for _, stmt := range decl.Body {
+		l, ok := stmt.(frontend.Local)
+		if !ok {
+			continue
+		}
+		reg := Reg(len(decl.Params) + len(local.locals))
+		if int(reg) >= MaxRegCount {
+			return 0, errors.Wrap(errors.New(
+				"out of registers: the target has %d registers", MaxRegCount,
+			), l)
+		}
+		if err := local.defineReg(l.Name, reg); err != nil {
+			return 0, errors.Wrap(err, l)
+		}
+		local.locals = append(local.locals, reg)
+		n += g.emit(Ins{
+			Op:   PushR,
+			Args: []Psuedo{ reg },
+		})
+	}
 	return n, nil
 }
 
+// Restores the registers of the locals of the current procedure, which must
+// happen before it returns.
+func (g *gen) restoreLocals() (n int) {
+	locals := g.localScope().locals
+	for i := len(locals) - 1; i >= 0; i-- {
+		n += g.emit(Ins{
+			Op:   PopR,
+			Args: []Psuedo{ locals[i] },
+		})
+	}
+	return
+}
+
+// Defines a constant in the current scope. Its value is a number literal or
+// another constant, and it takes no instructions.
+func (g *gen) constant(c frontend.Const) (int, error) {
+	var num Num
+	switch value := c.Value.(type) {
+	case frontend.NumLit, frontend.NumAlias:
+		ps, err := g.localScope().lookup(value)
+		if err != nil {
+			return 0, err
+		}
+		var ok bool
+		if num, ok = ps.(Num); !ok {
+			return 0, errors.New("%s is a parameter, not a constant", value)
+		}
+	default:
+		return 0, errors.TypeMismatch(num, value)
+	}
+	return 0, g.localScope().defineConst(c.Name, num)
+}
+
+// Defines a register alias in the current scope. Its value is a register
+// alias that's already visible there, and it takes no instructions.
+func (g *gen) regDecl(r frontend.RegDecl) (int, error) {
+	value, ok := r.Value.(frontend.RegAlias)
+	if !ok {
+		return 0, errors.TypeMismatch(Reg(0), r.Value)
+	}
+	ps, err := g.localScope().lookup(value)
+	if err != nil {
+		if n, numErr := strconv.Atoi(value.String()); numErr == nil && n >= MaxRegCount {
+			return 0, errors.New(
+				"no register %d: the target has %d registers", n, MaxRegCount,
+			)
+		}
+		return 0, err
+	}
+	return 0, g.localScope().defineReg(r.Name, ps.(Reg))
+}
+
 func (g *gen) procCall(cmd Cmd, args []Psuedo) (n int) {
-	n += g.procCallProlog(args)
+	if g.tail && g.inPlace(args) {
+		// Nothing but a ret follows and the prolog and epilog would only
+		// save and restore each argument in place, so the callee can return
+		// straight to the caller of the current procedure. Locals are
+		// restored first, since nothing runs after the callee returns.
+		n += g.restoreLocals()
+		n += g.emit(Ins{
+			Op:   TailI,
+			Args: []Psuedo{ cmd.Addr },
+		})
+		return
+	}
+
+	n += g.procCallProlog(args, cmd)
 	n += g.emit(Ins{
-		Name: "CALL_I",
+		Op:   CallI,
 		Args: []Psuedo{ cmd.Addr },
 	})
-	n += g.procCallEpilog(args)
+	n += g.procCallEpilog(args, cmd)
 	return
 }
 
-func (g *gen) procCallProlog(args []Psuedo) (n int) {
+// Returns true if every argument is already in the register of its param,
+// and that register is a param of the current procedure rather than a local.
+func (g *gen) inPlace(args []Psuedo) bool {
+	params := len(g.context().Params)
+	for i, arg := range args {
+		if reg, ok := arg.(Reg); !ok || int(reg) != i || i >= params {
+			return false
+		}
+	}
+	return true
+}
+
+// Generates the psuedo-instructions before a call, which move the arguments
+// into the registers of their params. Out params aren't read by the callee, so
+// nothing is moved into them, though their registers are still saved.
+func (g *gen) procCallProlog(args []Psuedo, cmd Cmd) (n int) {
 	// See depSeqs definition for info about dependency sequences.
 	regSeqs, numSeqs := depSeqs(args)
 
 	// Generate psuedo-instructions for handling dep seqs that start with
 	// numbers.
-	for num, seq := range numSeqs {
-		i := len(seq) - 1
+	for _, num := range seqOrder(numSeqs) {
+		seq := numSeqs[num]
 		n += g.emit(Ins{
-			Name: "PUSH_R",
-			Args: []Psuedo{ Reg(seq[i]) },
+			Op:   PushR,
+			Args: []Psuedo{ Reg(seq[len(seq)-1]) },
 		})
-		for i--; i >= 0; i-- {
-			n += g.emit(Ins{
-				Name: "MOVE_R",
-				Args: []Psuedo{ Reg(seq[i]), Reg(seq[i+1]) },
-			})
-		}
+		n += g.shift(seq, cmd)
 		n += g.emit(Ins{
-			Name: "MOVE_I",
+			Op:   MoveI,
 			Args: []Psuedo{ Num(num), Reg(seq[0]) },
 		})
 	}
 
 	// Generate psuedo-instructions for handling dep seqs that start with
 	// registers.
-	for reg, seq := range regSeqs {
-		i := len(seq) - 1
+	for _, reg := range seqOrder(regSeqs) {
+		seq := regSeqs[reg]
+		if inPlaceSeq(reg, seq) {
+			continue
+		}
 		n += g.emit(Ins{
-			Name: "PUSH_R",
-			Args: []Psuedo{ Reg(seq[i]) },
+			Op:   PushR,
+			Args: []Psuedo{ Reg(seq[len(seq)-1]) },
 		})
-		for i--; i >= 0; i-- {
-			n += g.emit(Ins{
-				Name: "MOVE_R",
-				Args: []Psuedo{ Reg(seq[i]), Reg(seq[i+1]) },
-			})
-		}
+		n += g.shift(seq, cmd)
 
 		// Handle cyclic dep seqs.
 		if seq[len(seq)-1] == reg {
 			n += g.emit(Ins{
-				Name: "POP_R",
+				Op:   PopR,
 				Args: []Psuedo{ Reg(seq[0]) },
 			})
-		} else {
+		} else if cmd.reads(seq[0]) {
 			n += g.emit(Ins{
-				Name: "MOVE_R",
+				Op:   MoveR,
 				Args: []Psuedo{ Reg(reg), Reg(seq[0]) },
 			})
 		}
@@ -187,49 +477,77 @@ func (g *gen) procCallProlog(args []Psuedo) (n int) {
 	return
 }
 
-func (g *gen) procCallEpilog(args []Psuedo) (n int) {
-	// See depSeqs definition for info about dependency sequences.
-	regSeqs, numSeqs := depSeqs(args)
-
-	// Generate psuedo-instructions for handling dep seqs that start with
-	// numbers.
-	for _, seq := range numSeqs {
-		for i := 1; i < len(seq); i++ {
+// Moves the contents of each register of a dep seq into the next one, from the
+// end of the dep seq, skipping those that go into out params.
+func (g *gen) shift(seq []int, cmd Cmd) (n int) {
+	for i := len(seq) - 2; i >= 0; i-- {
+		if cmd.reads(seq[i+1]) {
 			n += g.emit(Ins{
-				Name: "MOVE_R",
-				Args: []Psuedo{ Reg(seq[i]), Reg(seq[i-1]) },
+				Op:   MoveR,
+				Args: []Psuedo{ Reg(seq[i]), Reg(seq[i+1]) },
 			})
 		}
-		n += g.emit(Ins{
-			Name: "POP_R",
-			Args: []Psuedo{ Reg(seq[len(seq)-1]) },
-		})
 	}
+	return
+}
+
+// Generates the psuedo-instructions after a call, which put back the registers
+// the prolog moved. Registers passed to in params and num params are passed by
+// value, so they're only written to if the prolog used them for other
+// arguments.
+func (g *gen) procCallEpilog(args []Psuedo, cmd Cmd) (n int) {
+	// See depSeqs definition for info about dependency sequences. The prolog
+	// leaves values on the stack, so the epilog handles dep seqs in the reverse
+	// of the order the prolog did.
+	regSeqs, numSeqs := depSeqs(args)
 
 	// Generate psuedo-instructions for handling dep seqs that start with
 	// registers.
-	for reg, seq := range regSeqs {
+	regOrder := seqOrder(regSeqs)
+	for k := len(regOrder) - 1; k >= 0; k-- {
+		reg, seq := regOrder[k], regSeqs[regOrder[k]]
+		if inPlaceSeq(reg, seq) {
+			continue
+		}
+
 		// Handle cyclic dep seqs.
 		if i := len(seq)-1; reg == seq[i] {
 			n += g.emit(Ins{
-				Name: "PUSH_R",
+				Op:   PushR,
 				Args: []Psuedo{ Reg(seq[0]) },
 			})
-		} else {
+		} else if cmd.writes(seq[0]) || reg < len(args) {
 			n += g.emit(Ins{
-				Name: "MOVE_R",
+				Op:   MoveR,
 				Args: []Psuedo{ Reg(seq[0]), Reg(reg) },
 			})
 		}
 
 		for i := 1; i < len(seq); i++ {
 			n += g.emit(Ins{
-				Name: "MOVE_R",
+				Op:   MoveR,
 				Args: []Psuedo{ Reg(seq[i]), Reg(seq[i-1]) },
 			})
 		}
 		n += g.emit(Ins{
-			Name: "POP_R",
+			Op:   PopR,
+			Args: []Psuedo{ Reg(seq[len(seq)-1]) },
+		})
+	}
+
+	// Generate psuedo-instructions for handling dep seqs that start with
+	// numbers.
+	numOrder := seqOrder(numSeqs)
+	for k := len(numOrder) - 1; k >= 0; k-- {
+		seq := numSeqs[numOrder[k]]
+		for i := 1; i < len(seq); i++ {
+			n += g.emit(Ins{
+				Op:   MoveR,
+				Args: []Psuedo{ Reg(seq[i]), Reg(seq[i-1]) },
+			})
+		}
+		n += g.emit(Ins{
+			Op:   PopR,
 			Args: []Psuedo{ Reg(seq[len(seq)-1]) },
 		})
 	}
@@ -237,6 +555,36 @@ func (g *gen) procCallEpilog(args []Psuedo) (n int) {
 	return
 }
 
+// Returns true if a procedure reads its param i before writing it, i.e. it
+// isn't an out param.
+func (c Cmd) reads(i int) bool {
+	return i >= len(c.Modes) || c.Modes[i] != frontend.Out
+}
+
+// Returns true if a procedure can write its param i, i.e. it isn't an in
+// param or a num param.
+func (c Cmd) writes(i int) bool {
+	return i >= len(c.Modes) || c.Modes[i] != frontend.In
+}
+
+// Returns the starts of dep seqs in the order prologs handle them. Sorting
+// keeps the generated code deterministic.
+func seqOrder(seqs map[int][]int) []int {
+	order := make([]int, 0, len(seqs))
+	for start := range seqs {
+		order = append(order, start)
+	}
+	sort.Ints(order)
+	return order
+}
+
+// Returns true if a dep seq starting with a register only passes it to its own
+// param. Saving the register and restoring it straight away would do nothing,
+// so prologs and epilogs leave it alone.
+func inPlaceSeq(reg int, seq []int) bool {
+	return len(seq) == 1 && seq[0] == reg
+}
+
 // Returns "dependency sequences" for generating instructions to perform
 // maximally in-place, stack-assisted register reorderings that occurs in call
 // prologs/epilogs. A dependency sequence A, B, C means:
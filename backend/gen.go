@@ -1,16 +1,45 @@
 package backend
 
 import (
+	"fmt"
+
 	"github.com/ialeinbach/imp/frontend"
 )
 
 type gen struct{
 	scopes []*scope
 	code   []Ins
+	labels int
+
+	// Procedures called but not declared, by name. Calls to undefined
+	// procedures are errors unless this is non-nil.
+	imports map[string]*Import
+
+	// Whether the call being generated is in tail position, i.e. followed
+	// by an unconditional ret.
+	tail bool
 }
 
-func (g *gen) here() Num {
-	return Num(len(g.code))
+// Returns a label that's yet to be marked.
+func (g *gen) newLabel() Label {
+	g.labels++
+	return Label(g.labels - 1)
+}
+
+// Marks the position of the next instruction with a label.
+func (g *gen) mark(l Label) int {
+	return g.emit(Ins{
+		Op:   Mark,
+		Args: []Psuedo{ l },
+	})
+}
+
+// Marks the start of the top-level code, which is what rec calls outside of
+// any procedure. Must come before any other code or label.
+func (g *gen) start() {
+	global := g.scopes[0]
+	global.context.Addr = g.newLabel()
+	g.mark(global.context.Addr)
 }
 
 func (g *gen) localScope() *scope {
@@ -21,14 +50,16 @@ func (g *gen) localScope() *scope {
 }
 
 func (g *gen) context() Cmd {
-	return g.localScope().cmds[g.localScope().name]
+	return g.localScope().context
 }
 
-func (g *gen) enterScope(context frontend.Decl) error {
+func (g *gen) enterScope(context frontend.Decl, cmd Cmd) error {
 	local, err := innerScope(context)
 	if err != nil {
 		return err
 	}
+	local.context = cmd
+	local.outer = g.localScope()
 	g.scopes = append(g.scopes, local)
 	return nil
 }
@@ -47,15 +78,18 @@ func (g *gen) lookup(alias frontend.Alias) (ps Psuedo, err error) {
 	return // failed lookup returns same error for any scope
 }
 
-func (g *gen) define(name string, cmd Cmd) {
-	g.localScope().define(name, cmd)
-}
-
 func (g *gen) typecheck(args []frontend.Alias, params []Psuedo) ([]Psuedo, error) {
 	return g.localScope().typecheck(args, params)
 }
 
+// Appends instructions to the generated code. Instructions that don't match
+// the operands declared for their opcode are bugs in the generator.
 func (g *gen) emit(i ...Ins) int {
+	for _, ins := range i {
+		if err := ins.Validate(); err != nil {
+			panic(fmt.Sprintf("backend: emitted invalid instruction %s: %s", ins, err))
+		}
+	}
 	g.code = append(g.code, i...)
 	return len(i)
 }
@@ -0,0 +1,299 @@
+package backend
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Flag-configurables.
+var (
+	GoPackageFlag string = "imp"
+)
+
+func init() {
+	Register(target{
+		name:  "go",
+		regs:  8,
+		args:  6,
+		lower: lowerGo,
+	})
+}
+
+const goPrelude = `// Code generated by imp. DO NOT EDIT.
+
+package %s
+
+// Machine state of the program. Return addresses live on the Go stack, so the
+// stack only holds values pushed by PUSH_I and PUSH_R.
+type machine struct {
+	r     [%d]int64
+	stack []int64
+}
+
+// Runs the program with the given initial registers and returns register 0.
+// Each call uses fresh machine state, so Run is safe for concurrent use.
+func Run(regs [%d]int64) int64 {
+	m := &machine{r: regs}
+	return m.main()
+}
+
+func (m *machine) push(v int64) {
+	m.stack = append(m.stack, v)
+}
+
+func (m *machine) pop() int64 {
+	top := len(m.stack) - 1
+	if top < 0 {
+		panic("imp: cannot pop empty stack")
+	}
+	v := m.stack[top]
+	m.stack = m.stack[:top]
+	return v
+}
+`
+
+// Lowers psuedo-instructions to a Go package whose exported Run function
+// executes the program. Each procedure becomes a method and jumps within a
+// procedure become gotos.
+func lowerGo(psuedo []Ins) ([]byte, error) {
+	procs, err := Procs(psuedo)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(goPrelude, GoPackageFlag, MaxRegCount, MaxRegCount))
+
+	for i, p := range procs {
+		ret := "return"
+		if i == 0 {
+			ret = "return m.r[0]"
+			b.WriteString("\nfunc (m *machine) main() int64 {\n")
+		} else {
+			b.WriteString(fmt.Sprintf("\nfunc (m *machine) proc%d() {\n", p.Addr))
+		}
+
+		body := p.Body(procs)
+		live, targets, err := goLive(psuedo, p, body)
+		if err != nil {
+			return nil, err
+		}
+
+		returned := false
+		for _, addr := range live {
+			ins := psuedo[addr]
+			if targets[addr] {
+				b.WriteString(fmt.Sprintf("L%d:\n", addr))
+				returned = false
+			}
+			if goFallsThrough(ins, addr, live) {
+				continue
+			}
+			stmt, err := goIns(ins, ret)
+			if err != nil {
+				return nil, errors.New("ins %d (%s): %s", addr, ins, err)
+			}
+			if strings.Contains(stmt, "\n") {
+				b.WriteString(fmt.Sprintf("\t// %s\n\t%s\n", ins, stmt))
+			} else {
+				b.WriteString(fmt.Sprintf("\t%s // %s\n", stmt, ins))
+			}
+			returned = goTerminates(ins)
+		}
+		if targets[p.End] {
+			b.WriteString(fmt.Sprintf("L%d:\n", p.End))
+			returned = false
+		}
+		if !returned {
+			b.WriteString("\t" + ret + "\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	out, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, errors.New("generated invalid Go: %s", err)
+	}
+	return out, nil
+}
+
+// Returns the addresses of body that control can reach from the start of p,
+// in order, and the addresses that the gotos among them go to. Go rejects
+// unused labels and go vet reports unreachable code, so neither is emitted.
+func goLive(psuedo []Ins, p Proc, body []Num) ([]Num, map[Num]bool, error) {
+	if _, err := jumpTargets(psuedo, p, body); err != nil {
+		return nil, nil, err
+	}
+
+	next := make(map[Num]Num)
+	for i, addr := range body {
+		next[addr] = p.End
+		if i+1 < len(body) {
+			next[addr] = body[i+1]
+		}
+	}
+
+	reached := make(map[Num]bool)
+	work := []Num{p.Addr}
+	for len(work) > 0 {
+		addr := work[len(work)-1]
+		work = work[:len(work)-1]
+		if _, ok := next[addr]; !ok || reached[addr] {
+			continue
+		}
+		reached[addr] = true
+
+		ins := psuedo[addr]
+		switch ins.Op {
+		case JumpI:
+			to, _ := insAddr(ins, 0) // ensured by jumpTargets()
+			work = append(work, to)
+		case BneI, BneR:
+			to, _ := insAddr(ins, 2) // ensured by jumpTargets()
+			work = append(work, to, next[addr])
+		default:
+			if !goTerminates(ins) {
+				work = append(work, next[addr])
+			}
+		}
+	}
+
+	var live []Num
+	for _, addr := range body {
+		if reached[addr] {
+			live = append(live, addr)
+		}
+	}
+	targets := make(map[Num]bool)
+	for _, addr := range live {
+		ins := psuedo[addr]
+		switch {
+		case goFallsThrough(ins, addr, live):
+		case ins.Op == JumpI:
+			to, _ := insAddr(ins, 0)
+			targets[to] = true
+		case ins.Op == BneI || ins.Op == BneR:
+			to, _ := insAddr(ins, 2)
+			targets[to] = true
+		}
+	}
+	return live, targets, nil
+}
+
+// Returns true if ins is a JUMP_I to the instruction that follows it in live,
+// which needs no goto.
+func goFallsThrough(ins Ins, addr Num, live []Num) bool {
+	if ins.Op != JumpI {
+		return false
+	}
+	to, err := insAddr(ins, 0)
+	if err != nil {
+		return false
+	}
+	for i, a := range live {
+		if a == addr {
+			return i+1 < len(live) && live[i+1] == to
+		}
+	}
+	return false
+}
+
+// Returns true if control never continues past the Go statement for ins.
+func goTerminates(ins Ins) bool {
+	return ins.Op == JumpI || ins.Op == TailI || ins.Op == Ret
+}
+
+// Returns the Go statement implementing a single psuedo-instruction. ret is
+// the statement that returns from the enclosing function.
+func goIns(ins Ins, ret string) (string, error) {
+	switch ins.Op {
+	case MoveI:
+		n, d, err := numReg(ins)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("m.r[%d] = %d", d, n), nil
+	case MoveR:
+		s, d, err := regReg(ins)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("m.r[%d] = m.r[%d]", d, s), nil
+	case AddR, SubR:
+		s, d, err := regReg(ins)
+		if err != nil {
+			return "", err
+		}
+		op := map[Opcode]string{AddR: "+=", SubR: "-="}[ins.Op]
+		return fmt.Sprintf("m.r[%d] %s m.r[%d]", d, op, s), nil
+	case AddI, SubI:
+		n, d, err := numReg(ins)
+		if err != nil {
+			return "", err
+		}
+		op := map[Opcode]string{AddI: "+=", SubI: "-="}[ins.Op]
+		return fmt.Sprintf("m.r[%d] %s %d", d, op, n), nil
+	case BneI:
+		n, s, err := numReg(ins)
+		if err != nil {
+			return "", err
+		}
+		to, err := insAddr(ins, 2)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("if m.r[%d] != %d {\n\t\tgoto L%d\n\t}", s, n, to), nil
+	case BneR:
+		s0, s1, err := regReg(ins)
+		if err != nil {
+			return "", err
+		}
+		to, err := insAddr(ins, 2)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("if m.r[%d] != m.r[%d] {\n\t\tgoto L%d\n\t}", s0, s1, to), nil
+	case JumpI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("goto L%d", to), nil
+	case CallI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("m.proc%d()", to), nil
+	case TailI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("m.proc%d()\n\t%s", to, ret), nil
+	case Ret:
+		return ret, nil
+	case PushI:
+		n, err := insNum(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("m.push(%d)", n), nil
+	case PushR:
+		s, err := insReg(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("m.push(m.r[%d])", s), nil
+	case PopR:
+		d, err := insReg(ins, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("m.r[%d] = m.pop()", d), nil
+	}
+	return "", errors.Unsupported("%s instructions", ins.Op)
+}
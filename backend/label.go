@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Returns code with its LABEL markers removed and every label operand
+// replaced by the address it marks. Code can refer to labels before they're
+// marked, so instructions can be inserted or deleted freely until then.
+func Resolve(code []Ins) ([]Ins, error) {
+	addrs := make(map[Label]Num)
+	var here Num
+	for _, ins := range code {
+		if ins.Op != Mark {
+			here++
+			continue
+		}
+		l, err := insLabel(ins, 0)
+		if err != nil {
+			return nil, errors.New("%s: %s", ins, err)
+		}
+		if _, ok := addrs[l]; ok {
+			return nil, errors.New("label %s marked twice", l)
+		}
+		addrs[l] = here
+	}
+
+	psuedo := make([]Ins, 0, here)
+	for _, ins := range code {
+		if ins.Op == Mark {
+			continue
+		}
+		copied := false
+		for k, arg := range ins.Args {
+			l, ok := arg.(Label)
+			if !ok {
+				continue
+			}
+			addr, ok := addrs[l]
+			if !ok {
+				return nil, errors.New("%s: label %s never marked", ins, l)
+			}
+			if !copied {
+				// Leaves the operands of the original instruction alone.
+				ins.Args = append([]Psuedo(nil), ins.Args...)
+				copied = true
+			}
+			ins.Args[k] = addr
+		}
+		psuedo = append(psuedo, ins)
+	}
+
+	if err := Check(psuedo); err != nil {
+		return nil, err
+	}
+	return psuedo, nil
+}
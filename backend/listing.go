@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Extension of psuedo-instruction listings, which imp and twerp accept in
+// place of source.
+const PsuedoExt = ".imps"
+
+// Parses a listing of psuedo-instructions, as printed by DumpPsuedo, into the
+// instructions it lists. Each line holds at most one instruction and may
+// start with its address, which must be right, followed by a colon. Text
+// after a '#' is the comment of the instruction on that line.
+//
+// Lines may also define labels, which are names followed by a colon, either
+// alone or before an instruction. A label stands for the address of the next
+// instruction and can be used in place of any address operand:
+//
+//	loop:
+//	    SUB_I 1 0
+//	    BNE_I 0 0 loop    # until zero
+func ParsePsuedo(src string) ([]Ins, error) {
+	var (
+		code   []Ins
+		here   Num
+		labels = make(map[string]Label)
+		marked = make(map[string]bool)
+		used   = make(map[string]int) // line of the first use
+	)
+	label := func(name string) Label {
+		if _, ok := labels[name]; !ok {
+			labels[name] = Label(len(labels))
+		}
+		return labels[name]
+	}
+
+	for i, text := range strings.Split(src, "\n") {
+		line := i + 1
+
+		var comment string
+		if hash := strings.IndexByte(text, '#'); hash >= 0 {
+			text, comment = text[:hash], strings.TrimSpace(text[hash+1:])
+		}
+		fields := strings.Fields(text)
+
+		// Addresses and labels.
+		for len(fields) > 0 && strings.HasSuffix(fields[0], ":") {
+			name := strings.TrimSuffix(fields[0], ":")
+			fields = fields[1:]
+			if addr, err := strconv.ParseInt(name, 10, 64); err == nil {
+				if Num(addr) != here {
+					return nil, errors.Line(line, errors.New("address %d should be %d", addr, here))
+				}
+				continue
+			}
+			if !isLabel(name) {
+				return nil, errors.Line(line, errors.New("bad label %q", name))
+			}
+			if marked[name] {
+				return nil, errors.Line(line, errors.New("label %s redefined", name))
+			}
+			marked[name] = true
+			code = append(code, Ins{Op: Mark, Args: []Psuedo{label(name)}})
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		op, err := LookupOpcode(fields[0])
+		if err != nil {
+			return nil, errors.Line(line, err)
+		}
+		ins := Ins{Op: op, Comment: comment}
+		kinds := op.Operands()
+		if len(fields)-1 != len(kinds) {
+			return nil, errors.Line(line, errors.CountMismatch(len(kinds), len(fields)-1))
+		}
+		for k, field := range fields[1:] {
+			kind := kinds[k]
+			if kind == AddrOperand && isLabel(field) {
+				if _, ok := used[field]; !ok {
+					used[field] = line
+				}
+				ins.Args = append(ins.Args, label(field))
+				continue
+			}
+			n, err := strconv.ParseInt(field, 0, 64)
+			if err != nil {
+				return nil, errors.Line(line, errors.New("bad operand %q", field))
+			}
+			if kind == RegOperand {
+				ins.Args = append(ins.Args, Reg(n))
+			} else {
+				ins.Args = append(ins.Args, Num(n))
+			}
+		}
+		code = append(code, ins)
+		here++
+	}
+
+	// Reports the first undefined label.
+	var undefined string
+	for name, line := range used {
+		if !marked[name] && (undefined == "" || line < used[undefined]) {
+			undefined = name
+		}
+	}
+	if undefined != "" {
+		return nil, errors.Line(used[undefined], errors.New("undefined label %s", undefined))
+	}
+	return Resolve(code)
+}
+
+// Returns true if s can name a label. Labels can't start with a digit or a
+// sign, so that they can't be mistaken for numbers.
+func isLabel(s string) bool {
+	for i, rn := range s {
+		if !(rn == '_' || rn == '.' || unicode.IsLetter(rn) || i > 0 && unicode.IsDigit(rn)) {
+			return false
+		}
+	}
+	return len(s) > 0
+}
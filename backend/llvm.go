@@ -0,0 +1,271 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Slots in the psuedo stack of generated LLVM modules.
+const llvmStackSize = 1 << 16
+
+func init() {
+	Register(target{
+		name:  "llvm",
+		regs:  8,
+		args:  6,
+		lower: lowerLlvm,
+	})
+}
+
+const llvmPrelude = `; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [%[1]d x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %%v) {
+entry:
+  %%sp = load i64, ptr @sp
+  %%full = icmp uge i64 %%sp, %[1]d
+  br i1 %%full, label %%trap, label %%ok
+ok:
+  %%slot = getelementptr inbounds [%[1]d x i64], ptr @stack, i64 0, i64 %%sp
+  store i64 %%v, ptr %%slot
+  %%next = add i64 %%sp, 1
+  store i64 %%next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %%sp = load i64, ptr @sp
+  %%empty = icmp eq i64 %%sp, 0
+  br i1 %%empty, label %%trap, label %%ok
+ok:
+  %%next = sub i64 %%sp, 1
+  store i64 %%next, ptr @sp
+  %%slot = getelementptr inbounds [%[1]d x i64], ptr @stack, i64 0, i64 %%next
+  %%v = load i64, ptr %%slot
+  ret i64 %%v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %%r = alloca [%[2]d x i64]
+  store [%[2]d x i64] zeroinitializer, ptr %%r
+  %%ret = call i64 @imp_main(ptr %%r)
+  %%status = trunc i64 %%ret to i32
+  ret i32 %%status
+}
+`
+
+// Lowers psuedo-instructions to textual LLVM IR. Each procedure becomes a
+// function taking a pointer to the register file, which makes imp's
+// by-reference register parameters explicit: a callee reads and writes the
+// caller's registers through it after the prolog has shuffled them. The
+// top-level code becomes @imp_main, which returns register 0.
+//
+// Every psuedo-instruction gets a basic block of its own, which keeps
+// lowering jumps trivial. LLVM's simplifycfg and mem2reg passes clean this up.
+func lowerLlvm(psuedo []Ins) ([]byte, error) {
+	procs, err := Procs(psuedo)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(llvmPrelude, llvmStackSize, MaxRegCount))
+
+	for i, p := range procs {
+		f := llvmFunc{b: &b, main: i == 0}
+		if f.main {
+			b.WriteString("\ndefine internal i64 @imp_main(ptr %r) {\n")
+		} else {
+			b.WriteString(fmt.Sprintf("\ndefine internal void @proc%d(ptr %%r) {\n", p.Addr))
+		}
+
+		body := p.Body(procs)
+		if _, err := jumpTargets(psuedo, p, body); err != nil {
+			return nil, err
+		}
+
+		first := p.End
+		if len(body) > 0 {
+			first = body[0]
+		}
+		b.WriteString(fmt.Sprintf("entry:\n  br label %%L%d\n", first))
+		for k, addr := range body {
+			next := p.End
+			if k+1 < len(body) {
+				next = body[k+1]
+			}
+			ins := psuedo[addr]
+			b.WriteString(fmt.Sprintf("L%d: ; %s\n", addr, ins))
+			if err := f.ins(ins, next); err != nil {
+				return nil, errors.New("ins %d (%s): %s", addr, ins, err)
+			}
+		}
+		b.WriteString(fmt.Sprintf("L%d:\n", p.End))
+		f.ret()
+		b.WriteString("}\n")
+	}
+
+	return []byte(b.String()), nil
+}
+
+// State for lowering the body of one function.
+type llvmFunc struct {
+	b    *strings.Builder
+	main bool
+	tmp  int
+}
+
+// Writes an instruction whose result is a fresh temporary, which is returned.
+func (f *llvmFunc) def(format string, a ...interface{}) string {
+	f.tmp++
+	name := fmt.Sprintf("%%t%d", f.tmp)
+	f.b.WriteString(fmt.Sprintf("  %s = "+format+"\n", append([]interface{}{name}, a...)...))
+	return name
+}
+
+// Writes an instruction with no result.
+func (f *llvmFunc) do(format string, a ...interface{}) {
+	f.b.WriteString(fmt.Sprintf("  "+format+"\n", a...))
+}
+
+func (f *llvmFunc) slot(r Reg) string {
+	return f.def("getelementptr inbounds [%d x i64], ptr %%r, i64 0, i64 %d", MaxRegCount, r)
+}
+
+func (f *llvmFunc) load(r Reg) string {
+	return f.def("load i64, ptr %s", f.slot(r))
+}
+
+func (f *llvmFunc) store(val string, r Reg) {
+	f.do("store i64 %s, ptr %s", val, f.slot(r))
+}
+
+func (f *llvmFunc) ret() {
+	if f.main {
+		f.do("ret i64 %s", f.load(0))
+	} else {
+		f.do("ret void")
+	}
+}
+
+// Lowers a single psuedo-instruction, ending its basic block. next is the
+// address control falls through to.
+func (f *llvmFunc) ins(ins Ins, next Num) error {
+	switch ins.Op {
+	case MoveI:
+		n, d, err := numReg(ins)
+		if err != nil {
+			return err
+		}
+		f.store(n.String(), d)
+	case MoveR:
+		s, d, err := regReg(ins)
+		if err != nil {
+			return err
+		}
+		f.store(f.load(s), d)
+	case AddR, SubR:
+		s, d, err := regReg(ins)
+		if err != nil {
+			return err
+		}
+		op := map[Opcode]string{AddR: "add", SubR: "sub"}[ins.Op]
+		src := f.load(s)
+		f.store(f.def("%s i64 %s, %s", op, f.load(d), src), d)
+	case AddI, SubI:
+		n, d, err := numReg(ins)
+		if err != nil {
+			return err
+		}
+		op := map[Opcode]string{AddI: "add", SubI: "sub"}[ins.Op]
+		f.store(f.def("%s i64 %s, %s", op, f.load(d), n), d)
+	case BneI, BneR:
+		var left, right string
+		if ins.Op == BneI {
+			n, s, err := numReg(ins)
+			if err != nil {
+				return err
+			}
+			left, right = n.String(), f.load(s)
+		} else {
+			s0, s1, err := regReg(ins)
+			if err != nil {
+				return err
+			}
+			left, right = f.load(s0), f.load(s1)
+		}
+		to, err := insAddr(ins, 2)
+		if err != nil {
+			return err
+		}
+		cond := f.def("icmp ne i64 %s, %s", left, right)
+		f.do("br i1 %s, label %%L%d, label %%L%d", cond, to, next)
+		return nil
+	case JumpI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return err
+		}
+		f.do("br label %%L%d", to)
+		return nil
+	case CallI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return err
+		}
+		f.do("call void @proc%d(ptr %%r)", to)
+	case TailI:
+		to, err := insAddr(ins, 0)
+		if err != nil {
+			return err
+		}
+		if f.main {
+			f.do("call void @proc%d(ptr %%r)", to)
+		} else {
+			f.do("musttail call void @proc%d(ptr %%r)", to)
+		}
+		f.ret()
+		return nil
+	case Ret:
+		f.ret()
+		return nil
+	case PushI:
+		n, err := insNum(ins, 0)
+		if err != nil {
+			return err
+		}
+		f.do("call void @push(i64 %s)", n)
+	case PushR:
+		s, err := insReg(ins, 0)
+		if err != nil {
+			return err
+		}
+		f.do("call void @push(i64 %s)", f.load(s))
+	case PopR:
+		d, err := insReg(ins, 0)
+		if err != nil {
+			return err
+		}
+		f.store(f.def("call i64 @pop()"), d)
+	default:
+		return errors.Unsupported("%s instructions", ins.Op)
+	}
+	f.do("br label %%L%d", next)
+	return nil
+}
@@ -0,0 +1,364 @@
+package backend
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+	"github.com/ialeinbach/imp/frontend"
+)
+
+// Object files hold code that's yet to be linked. Their layout follows that
+// of bytecode files:
+//
+//	registers  uvarint
+//	labels     uvarint number of labels used by the code
+//	opcodes    uvarint count, then each name as a string
+//	exports    uvarint count, then a name string, uvarint label and
+//	           signature string each
+//	imports    uvarint count, then a name string, uvarint label, and a
+//	           uvarint count of signature strings each
+//	code       as in bytecode files, with label operands and LABEL markers
+const (
+	ObjectMagic   = "IMPO"
+	ObjectVersion = 2
+
+	// Extension of object files.
+	ObjectExt = ".impo"
+)
+
+// Kinds of parameters and arguments in signatures.
+const (
+	sigReg = 'r'
+	sigIn  = 'i'
+	sigOut = 'o'
+	sigNum = 'n'
+)
+
+// Kinds that can appear in the signatures of exports and of calls.
+const (
+	exportSigs = "rion"
+	callSigs   = "rin"
+)
+
+// Code compiled from one source file, with its procedures unresolved.
+type Object struct {
+	// Registers the code was compiled for.
+	Regs int
+
+	// Labels used by the code, which are numbered from 0.
+	Labels int
+
+	Exports []Export
+	Imports []Import
+
+	// Code with LABEL markers and label operands, to be resolved by Link.
+	Code []Ins
+}
+
+// A procedure declared at the top level of an object, which other objects
+// can call. Its signature has one character per parameter: 'r' for inout
+// registers, 'i' for in registers, 'o' for out registers and 'n' for numbers.
+type Export struct {
+	Name   string
+	Label  Label
+	Params string
+}
+
+// A procedure called by an object but not declared in it. Every call refers
+// to the label, and has a signature with one character per argument: 'r' for
+// registers, 'i' for read-only registers (in parameters) and 'n' for numbers
+// (or number parameters).
+type Import struct {
+	Name  string
+	Label Label
+	Calls []string
+}
+
+// Returns true if data starts with the object file magic number.
+func IsObject(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ObjectMagic))
+}
+
+// Compiles a program into an object. Unlike Flatten, calls to undefined
+// procedures become imports.
+func CompileObject(prog []frontend.Stmt) (*Object, error) {
+	g := &gen{
+		scopes:  []*scope{globalScope()},
+		code:    []Ins{},
+		imports: make(map[string]*Import),
+	}
+	g.start()
+	if _, err := g.prog(prog); err != nil {
+		return nil, err
+	}
+
+	obj := &Object{
+		Regs: MaxRegCount,
+		Code: g.code,
+	}
+	for name, cmd := range g.scopes[0].cmds {
+		obj.Exports = append(obj.Exports, Export{
+			Name:   name,
+			Label:  cmd.Addr,
+			Params: signature(cmd),
+		})
+	}
+	for _, imp := range g.imports {
+		obj.Imports = append(obj.Imports, *imp)
+	}
+	obj.Labels = g.labels
+
+	// Keeps object files deterministic.
+	sort.Slice(obj.Exports, func(i, j int) bool {
+		return obj.Exports[i].Name < obj.Exports[j].Name
+	})
+	sort.Slice(obj.Imports, func(i, j int) bool {
+		return obj.Imports[i].Name < obj.Imports[j].Name
+	})
+	return obj, nil
+}
+
+// Returns the signature of a procedure's parameters.
+func signature(cmd Cmd) string {
+	var b strings.Builder
+	for i, param := range cmd.Params {
+		_, isReg := param.(Reg)
+		switch {
+		case !isReg:
+			b.WriteByte(sigNum)
+		case !cmd.writes(i):
+			b.WriteByte(sigIn)
+		case !cmd.reads(i):
+			b.WriteByte(sigOut)
+		default:
+			b.WriteByte(sigReg)
+		}
+	}
+	return b.String()
+}
+
+// Returns true if a call with signature call can pass its arguments to
+// parameters with signature params. Like in typecheck, register parameters
+// need registers, but number parameters take anything, and read-only
+// registers can only be passed to in parameters.
+func compatible(params, call string) bool {
+	if len(params) != len(call) {
+		return false
+	}
+	for i := range params {
+		switch {
+		case params[i] == sigNum:
+		case call[i] == sigNum:
+			return false
+		case call[i] == sigIn && params[i] != sigIn:
+			return false
+		}
+	}
+	return true
+}
+
+// Links objects into a program. The top-level code of each object runs in
+// the order given, and names are the files of the objects for errors.
+func Link(objs []*Object, names []string) ([]Ins, error) {
+	type def struct {
+		file   string
+		label  Label
+		params string
+	}
+
+	// Labels of each object are offset past those of the objects before it.
+	offsets := make([]Label, len(objs))
+	exports := make(map[string]def)
+	var labels Label
+	for i, obj := range objs {
+		if obj.Regs > MaxRegCount {
+			return nil, errors.New(
+				"%s: object needs %d registers but the target has %d", names[i], obj.Regs, MaxRegCount,
+			)
+		}
+		offsets[i] = labels
+		for _, exp := range obj.Exports {
+			if prev, ok := exports[exp.Name]; ok {
+				return nil, errors.New(
+					"duplicate symbol %s: defined in %s and %s", exp.Name, prev.file, names[i],
+				)
+			}
+			exports[exp.Name] = def{names[i], exp.Label + labels, exp.Params}
+		}
+		labels += Label(obj.Labels)
+	}
+
+	var code []Ins
+	for i, obj := range objs {
+		bound := make(map[Label]Label)
+		for _, imp := range obj.Imports {
+			exp, ok := exports[imp.Name]
+			if !ok {
+				return nil, errors.New("undefined symbol %s: referenced in %s", imp.Name, names[i])
+			}
+			for _, call := range imp.Calls {
+				if !compatible(exp.params, call) {
+					return nil, errors.New(
+						"signature mismatch for %s: %s declares (%s) but %s calls it with (%s)",
+						imp.Name, exp.file, sigString(exp.params), names[i], sigString(call),
+					)
+				}
+			}
+			bound[imp.Label] = exp.label
+		}
+
+		for _, ins := range obj.Code {
+			args := make([]Psuedo, len(ins.Args))
+			for k, arg := range ins.Args {
+				if l, ok := arg.(Label); ok {
+					if to, ok := bound[l]; ok {
+						arg = to
+					} else {
+						arg = l + offsets[i]
+					}
+				}
+				args[k] = arg
+			}
+			if len(args) == 0 {
+				args = nil
+			}
+			ins.Args = args
+			code = append(code, ins)
+		}
+	}
+
+	return Resolve(code)
+}
+
+// Returns a readable form of a signature, e.g. "reg, in reg, num".
+func sigString(sig string) string {
+	kinds := make([]string, len(sig))
+	for i := range sig {
+		switch sig[i] {
+		case sigReg:
+			kinds[i] = "reg"
+		case sigIn:
+			kinds[i] = "in reg"
+		case sigOut:
+			kinds[i] = "out reg"
+		default:
+			kinds[i] = "num"
+		}
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// Encodes an object.
+func EncodeObject(obj *Object) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(ObjectMagic)
+	b.WriteByte(ObjectVersion)
+	writeUvarint(&b, uint64(obj.Regs))
+	writeUvarint(&b, uint64(obj.Labels))
+	index := writeOpcodes(&b, obj.Code)
+
+	writeUvarint(&b, uint64(len(obj.Exports)))
+	for _, exp := range obj.Exports {
+		writeString(&b, exp.Name)
+		writeUvarint(&b, uint64(exp.Label))
+		writeString(&b, exp.Params)
+	}
+
+	writeUvarint(&b, uint64(len(obj.Imports)))
+	for _, imp := range obj.Imports {
+		writeString(&b, imp.Name)
+		writeUvarint(&b, uint64(imp.Label))
+		writeUvarint(&b, uint64(len(imp.Calls)))
+		for _, call := range imp.Calls {
+			writeString(&b, call)
+		}
+	}
+
+	if err := writeCode(&b, obj.Code, index); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Decodes an object.
+func DecodeObject(data []byte) (*Object, error) {
+	if !IsObject(data) {
+		return nil, errors.New("object: bad magic number")
+	}
+	r := &bytecodeReader{r: bytes.NewReader(data[len(ObjectMagic):])}
+
+	if v := r.byte(); r.err == nil && v != ObjectVersion {
+		return nil, errors.New("object: unsupported version %d", v)
+	}
+	regs := r.uvarint()
+	obj := &Object{Regs: int(regs), Labels: r.count()}
+
+	ops, err := r.opcodes()
+	if err != nil {
+		return nil, errors.New("object: %s", err)
+	}
+
+	obj.Exports = make([]Export, r.count())
+	for i := range obj.Exports {
+		obj.Exports[i] = Export{Name: r.string(), Label: Label(r.uvarint()), Params: r.string()}
+	}
+	obj.Imports = make([]Import, r.count())
+	for i := range obj.Imports {
+		imp := Import{Name: r.string(), Label: Label(r.uvarint())}
+		imp.Calls = make([]string, r.count())
+		for k := range imp.Calls {
+			imp.Calls[k] = r.string()
+		}
+		obj.Imports[i] = imp
+	}
+
+	if obj.Code, err = r.code(ops, regs); err != nil {
+		return nil, errors.New("object: %s", err)
+	}
+	if r.r.Len() > 0 {
+		return nil, errors.New("object: trailing data")
+	}
+
+	// Every label must be in range for Link to keep the labels of different
+	// objects apart.
+	inRange := func(l Label) bool {
+		return l >= 0 && int(l) < obj.Labels
+	}
+	for _, exp := range obj.Exports {
+		if !inRange(exp.Label) || !validSig(exp.Params, exportSigs) {
+			return nil, errors.New("object: bad export %s", exp.Name)
+		}
+	}
+	for _, imp := range obj.Imports {
+		if !inRange(imp.Label) {
+			return nil, errors.New("object: bad import %s", imp.Name)
+		}
+		for _, call := range imp.Calls {
+			if !validSig(call, callSigs) {
+				return nil, errors.New("object: bad import %s", imp.Name)
+			}
+		}
+	}
+	for i, ins := range obj.Code {
+		if err := ins.Validate(); err != nil {
+			return nil, errors.New("object: ins %d (%s): %s", i, ins, err)
+		}
+		for _, arg := range ins.Args {
+			if l, ok := arg.(Label); ok && !inRange(l) {
+				return nil, errors.New("object: ins %d (%s): label out of range", i, ins)
+			}
+		}
+	}
+	return obj, nil
+}
+
+func validSig(sig string, kinds string) bool {
+	for i := range sig {
+		if strings.IndexByte(kinds, sig[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
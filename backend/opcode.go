@@ -0,0 +1,178 @@
+package backend
+
+import (
+	"fmt"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Operation performed by a psuedo-instruction.
+type Opcode int
+
+const (
+	MoveI Opcode = iota
+	MoveR
+	AddI
+	AddR
+	SubI
+	SubR
+	BneI
+	BneR
+	JumpI
+	CallI
+
+	// Jumps to a procedure like CALL_I, but without a return address of its
+	// own, so that it returns to the caller of the procedure making the call.
+	TailI
+
+	Ret
+	PushI
+	PushR
+	PopR
+
+	// Marks a position in code, defining its operand as the address of the
+	// next instruction. Markers are removed by Resolve, so they're never
+	// executed or lowered.
+	Mark
+)
+
+// Kind of an operand of a psuedo-instruction.
+type Operand int
+
+const (
+	// A register.
+	RegOperand Operand = iota
+
+	// A number.
+	NumOperand
+
+	// The address of an instruction, in [0, len(program)]. Before Resolve,
+	// it can also be a label.
+	AddrOperand
+
+	// A label.
+	LabelOperand
+)
+
+// Name and operands of every opcode. Everything that reads or writes
+// psuedo-instructions is driven from this table.
+var opcodes = [...]struct {
+	name     string
+	operands []Operand
+}{
+	MoveI: {"MOVE_I", []Operand{NumOperand, RegOperand}},
+	MoveR: {"MOVE_R", []Operand{RegOperand, RegOperand}},
+	AddI:  {"ADD_I", []Operand{NumOperand, RegOperand}},
+	AddR:  {"ADD_R", []Operand{RegOperand, RegOperand}},
+	SubI:  {"SUB_I", []Operand{NumOperand, RegOperand}},
+	SubR:  {"SUB_R", []Operand{RegOperand, RegOperand}},
+	BneI:  {"BNE_I", []Operand{NumOperand, RegOperand, AddrOperand}},
+	BneR:  {"BNE_R", []Operand{RegOperand, RegOperand, AddrOperand}},
+	JumpI: {"JUMP_I", []Operand{AddrOperand}},
+	CallI: {"CALL_I", []Operand{AddrOperand}},
+	TailI: {"TAIL_I", []Operand{AddrOperand}},
+	Ret:   {"RET", nil},
+	PushI: {"PUSH_I", []Operand{NumOperand}},
+	PushR: {"PUSH_R", []Operand{RegOperand}},
+	PopR:  {"POP_R", []Operand{RegOperand}},
+	Mark:  {"LABEL", []Operand{LabelOperand}},
+}
+
+// Returns every opcode of an executable instruction, in order. This leaves
+// out Mark.
+func Opcodes() []Opcode {
+	var ops []Opcode
+	for i := range opcodes {
+		if op := Opcode(i); op != Mark {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Returns the opcode of an executable instruction with a name.
+func LookupOpcode(name string) (Opcode, error) {
+	for _, op := range Opcodes() {
+		if op.String() == name {
+			return op, nil
+		}
+	}
+	return 0, errors.Unsupported("%s instructions", name)
+}
+
+func (op Opcode) Valid() bool {
+	return op >= 0 && int(op) < len(opcodes)
+}
+
+func (op Opcode) String() string {
+	if !op.Valid() {
+		return fmt.Sprintf("Opcode(%d)", int(op))
+	}
+	return opcodes[op].name
+}
+
+// Returns the kinds of the operands of an opcode, in order.
+func (op Opcode) Operands() []Operand {
+	if !op.Valid() {
+		return nil
+	}
+	return opcodes[op].operands
+}
+
+// Returns an error unless an instruction has the operands its opcode
+// declares and its registers are in range. Addresses are only checked to be
+// numbers or labels, since their range depends on the rest of the program.
+func (i Ins) Validate() error {
+	if !i.Op.Valid() {
+		return errors.Unsupported("%s instructions", i.Op)
+	}
+	kinds := i.Op.Operands()
+	if len(i.Args) != len(kinds) {
+		return errors.CountMismatch(len(kinds), len(i.Args))
+	}
+	for k, kind := range kinds {
+		var err error
+		switch kind {
+		case RegOperand:
+			_, err = insReg(i, k)
+		case NumOperand:
+			_, err = insNum(i, k)
+		case AddrOperand:
+			if _, ok := i.Args[k].(Label); !ok {
+				_, err = insAddr(i, k)
+			}
+		case LabelOperand:
+			_, err = insLabel(i, k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns an error unless every instruction of a program is valid and every
+// address operand is resolved and in range.
+func Check(psuedo []Ins) error {
+	for addr, ins := range psuedo {
+		if err := ins.Validate(); err != nil {
+			return errors.New("ins %d (%s): %s", addr, ins, err)
+		}
+		if ins.Op == Mark {
+			return errors.New("ins %d (%s): unresolved label", addr, ins)
+		}
+		for k, kind := range ins.Op.Operands() {
+			if kind != AddrOperand {
+				continue
+			}
+			to, err := insAddr(ins, k)
+			if err != nil {
+				return errors.New("ins %d (%s): unresolved label", addr, ins)
+			}
+			if to < 0 || int(to) > len(psuedo) {
+				return errors.New("ins %d (%s): address out of range", addr, ins)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"sort"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// A procedure recovered from a stream of psuedo-instructions. Targets without
+// a flat address space (e.g. ones with structured functions) lower each
+// procedure separately.
+type Proc struct {
+	// Address of the first instruction of the body.
+	Addr Num
+
+	// Address one past the last instruction of the body. Control reaching End
+	// returns from the procedure.
+	End Num
+}
+
+// Returns true if control can be transferred to addr from within p.
+func (p Proc) Contains(addr Num) bool {
+	return addr >= p.Addr && addr <= p.End
+}
+
+// Returns the procedures of a program, sorted by address. The first is always
+// the top-level code, which spans the whole program. Every other procedure
+// starts at the target of some CALL_I or TAIL_I and, since flattening a decl emits a
+// JUMP_I over its body, ends at the target of the JUMP_I just before it.
+//
+// Bodies of nested decls lie within the body of their enclosing procedure but
+// are jumped over, so lowering them as part of it is harmless.
+func Procs(psuedo []Ins) ([]Proc, error) {
+	entries := map[Num]bool{0: true}
+	for i, ins := range psuedo {
+		if ins.Op != CallI && ins.Op != TailI {
+			continue
+		}
+		addr, err := insAddr(ins, 0)
+		if err != nil {
+			return nil, errors.New("ins %d (%s): %s", i, ins, err)
+		}
+		if addr < 0 || int(addr) >= len(psuedo) {
+			return nil, errors.New("ins %d (%s): address out of range", i, ins)
+		}
+		entries[addr] = true
+	}
+
+	procs := make([]Proc, 0, len(entries))
+	for addr := range entries {
+		p := Proc{Addr: addr, End: Num(len(psuedo))}
+		if addr > 0 && psuedo[addr-1].Op == JumpI {
+			end, err := insAddr(psuedo[addr-1], 0)
+			if err == nil && end > addr && int(end) <= len(psuedo) {
+				p.End = end
+			}
+		}
+		procs = append(procs, p)
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].Addr < procs[j].Addr
+	})
+	return procs, nil
+}
+
+// Returns the addresses of the instructions that belong to p itself, i.e. its
+// body without the bodies of procedures nested in it. Control never enters a
+// nested body except through a call, so targets with structured functions
+// can leave them out.
+func (p Proc) Body(procs []Proc) []Num {
+	var body []Num
+	for addr := p.Addr; addr < p.End; addr++ {
+		nested := false
+		for _, q := range procs {
+			if q.Addr > p.Addr && q.End <= p.End && addr >= q.Addr && addr < q.End {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			body = append(body, addr)
+		}
+	}
+	return body
+}
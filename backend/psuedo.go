@@ -3,6 +3,9 @@ package backend
 import (
 	"fmt"
 	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+	"github.com/ialeinbach/imp/frontend"
 )
 
 //
@@ -17,18 +20,29 @@ type (
 	Reg int
 	Num int64
 	Cmd struct {
-		Addr   Num
+		Addr   Label
 		Params []Psuedo
+
+		// Mode of each param, which is always in for num params. Procedures
+		// in other objects have no modes, so their params are treated as
+		// inout.
+		Modes []frontend.Mode
 	}
+
+	// An address that's unknown until Resolve assigns it. A LABEL marker
+	// defines a label as the address of the instruction after it.
+	Label int
 )
 
-func (r Reg) Psuedo() {}
-func (n Num) Psuedo() {}
-func (c Cmd) Psuedo() {}
+func (r Reg) Psuedo()   {}
+func (n Num) Psuedo()   {}
+func (c Cmd) Psuedo()   {}
+func (l Label) Psuedo() {}
 
-func (r Reg) Type() string { return "Reg" }
-func (n Num) Type() string { return "Num" }
-func (c Cmd) Type() string { return "Cmd" }
+func (r Reg) Type() string   { return "Reg" }
+func (n Num) Type() string   { return "Num" }
+func (c Cmd) Type() string   { return "Cmd" }
+func (l Label) Type() string { return "Label" }
 
 func (r Reg) String() string {
 	return fmt.Sprint(int(r))
@@ -38,10 +52,14 @@ func (n Num) String() string {
 	return fmt.Sprint(int64(n))
 }
 
+func (l Label) String() string {
+	return fmt.Sprintf("L%d", int(l))
+}
+
 func (c Cmd) String() string {
 	var b strings.Builder
 
-	b.WriteString(fmt.Sprintf("[Addr: %d, Params:", c.Addr))
+	b.WriteString(fmt.Sprintf("[Addr: %v, Params:", c.Addr))
 	for _, typ := range c.Params {
 		b.WriteString(fmt.Sprintf(" %v", typ))
 	}
@@ -55,14 +73,14 @@ func (c Cmd) String() string {
 //
 
 type Ins struct {
-	Name    string
+	Op      Opcode
 	Args    []Psuedo
 	Comment string
 }
 
 func (i Ins) String() string {
 	var b strings.Builder
-	b.WriteString(i.Name)
+	b.WriteString(i.Op.String())
 	for _, arg := range i.Args {
 		b.WriteString(fmt.Sprintf(" %v", arg))
 	}
@@ -73,3 +91,68 @@ func (i Ins) WithComment(format string, a ...interface{}) Ins {
 	i.Comment = fmt.Sprintf(format, a...)
 	return i
 }
+
+// Returns the register operand at index i of an instruction.
+func insReg(ins Ins, i int) (Reg, error) {
+	if i >= len(ins.Args) {
+		return 0, errors.CountMismatch(i+1, len(ins.Args))
+	}
+	r, ok := ins.Args[i].(Reg)
+	if !ok {
+		return 0, errors.TypeMismatch(Reg(0), ins.Args[i])
+	}
+	if r < 0 || int(r) >= MaxRegCount {
+		return 0, errors.New("register %d out of range", r)
+	}
+	return r, nil
+}
+
+// Returns the number operand at index i of an instruction.
+func insNum(ins Ins, i int) (Num, error) {
+	if i >= len(ins.Args) {
+		return 0, errors.CountMismatch(i+1, len(ins.Args))
+	}
+	n, ok := ins.Args[i].(Num)
+	if !ok {
+		return 0, errors.TypeMismatch(Num(0), ins.Args[i])
+	}
+	return n, nil
+}
+
+// Returns the address operand at index i of an instruction. Addresses are
+// numbers in the range [0, len(program)] once labels are resolved.
+func insAddr(ins Ins, i int) (Num, error) {
+	return insNum(ins, i)
+}
+
+// Returns the label operand at index i of an instruction.
+func insLabel(ins Ins, i int) (Label, error) {
+	if i >= len(ins.Args) {
+		return 0, errors.CountMismatch(i+1, len(ins.Args))
+	}
+	l, ok := ins.Args[i].(Label)
+	if !ok {
+		return 0, errors.TypeMismatch(Label(0), ins.Args[i])
+	}
+	return l, nil
+}
+
+// Returns the operands of a two-register instruction.
+func regReg(ins Ins) (Reg, Reg, error) {
+	r0, err := insReg(ins, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	r1, err := insReg(ins, 1)
+	return r0, r1, err
+}
+
+// Returns the operands of a number-register instruction.
+func numReg(ins Ins) (Num, Reg, error) {
+	n, err := insNum(ins, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	r, err := insReg(ins, 1)
+	return n, r, err
+}
@@ -0,0 +1,181 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Hardware registers backing imp registers 0..7. The callee-saved registers
+// are used so that ra, sp and the temporaries keep their usual roles. t0 is a
+// scratch register for immediates.
+var riscv64Regs = [...]string{
+	"s1", "s2", "s3", "s4", "s5", "s6", "s7", "s8",
+}
+
+func init() {
+	Register(target{
+		name:  "riscv64",
+		regs:  len(riscv64Regs),
+		args:  6,
+		lower: lowerRiscv64,
+	})
+}
+
+// Lowers psuedo-instructions to RV64I GNU assembler for Linux. Execution
+// begins at _start with the first psuedo-instruction and the process exits
+// with register 0 as its status once control reaches the end of the program.
+//
+// Procedure calls follow the standard conventions: the return address is
+// passed in ra, and the caller spills ra to the stack around the call so that
+// recursion through rec works. PUSH_R and POP_R use the same stack through sp.
+func lowerRiscv64(psuedo []Ins) ([]byte, error) {
+	var b strings.Builder
+
+	b.WriteString("\t.text\n")
+	b.WriteString("\t.globl _start\n")
+	b.WriteString("_start:\n")
+
+	for i, ins := range psuedo {
+		b.WriteString(fmt.Sprintf(".L%d:\t\t# %s\n", i, ins))
+		asm, err := riscv64Ins(ins)
+		if err != nil {
+			return nil, errors.New("ins %d (%s): %s", i, ins, err)
+		}
+		for _, line := range asm {
+			b.WriteString("\t" + line + "\n")
+		}
+	}
+
+	// Exit with register 0 as the status.
+	b.WriteString(fmt.Sprintf(".L%d:\n", len(psuedo)))
+	b.WriteString(fmt.Sprintf("\tmv a0, %s\n", riscv64Regs[0]))
+	b.WriteString("\tli a7, 93\n")
+	b.WriteString("\tecall\n")
+
+	return []byte(b.String()), nil
+}
+
+// Returns the assembly lines implementing a single psuedo-instruction.
+func riscv64Ins(ins Ins) ([]string, error) {
+	switch ins.Op {
+	case MoveI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("li %s, %d", riscv64Regs[r], n)}, nil
+	case MoveR:
+		src, dst, err := regReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("mv %s, %s", riscv64Regs[dst], riscv64Regs[src])}, nil
+	case AddR, SubR:
+		src, dst, err := regReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		op := map[Opcode]string{AddR: "add", SubR: "sub"}[ins.Op]
+		return []string{
+			fmt.Sprintf("%s %s, %s, %s", op, riscv64Regs[dst], riscv64Regs[dst], riscv64Regs[src]),
+		}, nil
+	case AddI, SubI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		dst := riscv64Regs[r]
+		if ins.Op == SubI && fitsInt12(-n) {
+			return []string{fmt.Sprintf("addi %s, %s, %d", dst, dst, -n)}, nil
+		}
+		if ins.Op == AddI && fitsInt12(n) {
+			return []string{fmt.Sprintf("addi %s, %s, %d", dst, dst, n)}, nil
+		}
+		op := map[Opcode]string{AddI: "add", SubI: "sub"}[ins.Op]
+		return []string{
+			fmt.Sprintf("li t0, %d", n),
+			fmt.Sprintf("%s %s, %s, t0", op, dst, dst),
+		}, nil
+	case BneI:
+		n, r, err := numReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := insAddr(ins, 2)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("li t0, %d", n),
+			fmt.Sprintf("bne t0, %s, .L%d", riscv64Regs[r], addr),
+		}, nil
+	case BneR:
+		r0, r1, err := regReg(ins)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := insAddr(ins, 2)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("bne %s, %s, .L%d", riscv64Regs[r0], riscv64Regs[r1], addr),
+		}, nil
+	case CallI:
+		addr, err := insAddr(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			"addi sp, sp, -8",
+			"sd ra, 0(sp)",
+			fmt.Sprintf("jal ra, .L%d", addr),
+			"ld ra, 0(sp)",
+			"addi sp, sp, 8",
+		}, nil
+	case JumpI, TailI:
+		// The return address stays in ra for tail calls.
+		addr, err := insAddr(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("j .L%d", addr)}, nil
+	case Ret:
+		return []string{"ret"}, nil
+	case PushI:
+		n, err := insNum(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("li t0, %d", n),
+			"addi sp, sp, -8",
+			"sd t0, 0(sp)",
+		}, nil
+	case PushR:
+		r, err := insReg(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			"addi sp, sp, -8",
+			fmt.Sprintf("sd %s, 0(sp)", riscv64Regs[r]),
+		}, nil
+	case PopR:
+		r, err := insReg(ins, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("ld %s, 0(sp)", riscv64Regs[r]),
+			"addi sp, sp, 8",
+		}, nil
+	}
+	return nil, errors.Unsupported("%s instructions", ins.Op)
+}
+
+func fitsInt12(n Num) bool {
+	return n >= -2048 && n <= 2047
+}
@@ -10,18 +10,37 @@ import (
 )
 
 type scope struct {
-	name string
-	cmds map[string]Cmd
-	regs map[string]Reg
-	nums map[string]Reg
+	name   string
+	cmds   map[string]Cmd
+	regs   map[string]Reg
+	nums   map[string]Reg
+	consts map[string]Num
+
+	// Registers of the locals of a procedure, in the order they're saved.
+	locals []Reg
+
+	// Registers of in params, which are read-only.
+	inputs map[Reg]bool
+
+	// Enclosing scope, whose constants are visible in this one.
+	outer *scope
+
+	// Procedure whose body the scope is, which rec calls.
+	context Cmd
+
+	// Start of the body of the procedure, after its locals are saved, which
+	// rec jumps to in tail position.
+	body Label
 }
 
 func newScope(name string) *scope {
 	return &scope{
-		name: name,
-		cmds: make(map[string]Cmd),
-		regs: make(map[string]Reg),
-		nums: make(map[string]Reg),
+		name:   name,
+		cmds:   make(map[string]Cmd),
+		regs:   make(map[string]Reg),
+		nums:   make(map[string]Reg),
+		consts: make(map[string]Num),
+		inputs: make(map[Reg]bool),
 	}
 }
 
@@ -39,6 +58,13 @@ func (s scope) String() string {
 
 	b.WriteString("--------------------\n")
 
+	b.WriteString("  Constants\n")
+	for k, v := range s.consts {
+		b.WriteString(fmt.Sprintf("    #%s = %v\n", k, v))
+	}
+
+	b.WriteString("--------------------\n")
+
 	b.WriteString("  Commands\n")
 	for k, v := range s.cmds {
 		b.WriteString(fmt.Sprintf("    :%s = %v\n", k, v))
@@ -50,21 +76,11 @@ func (s scope) String() string {
 }
 
 func globalScope() *scope {
-	return &scope{
-		name: "__global__",
-		cmds: make(map[string]Cmd),
-		regs: map[string]Reg{
-			"0": Reg(0),
-			"1": Reg(1),
-			"2": Reg(2),
-			"3": Reg(3),
-			"4": Reg(4),
-			"5": Reg(5),
-			"6": Reg(6),
-			"7": Reg(7),
-		},
-		nums: make(map[string]Reg),
+	global := newScope("__global__")
+	for i := 0; i < MaxRegCount; i++ {
+		global.regs[strconv.Itoa(i)] = Reg(i)
 	}
+	return global
 }
 
 func innerScope(context frontend.Decl) (*scope, error) {
@@ -73,6 +89,9 @@ func innerScope(context frontend.Decl) (*scope, error) {
 		switch param := param.(type) {
 		case frontend.RegAlias:
 			local.regs[param.String()] = Reg(i)
+			if i < len(context.Modes) && context.Modes[i] == frontend.In {
+				local.inputs[Reg(i)] = true
+			}
 		case frontend.NumAlias:
 			local.nums[param.String()] = Reg(i)
 		default:
@@ -93,23 +112,50 @@ func (s *scope) lookup(alias frontend.Alias) (Psuedo, error) {
 			return reg, nil
 		}
 	case frontend.NumAlias:
-		// Always treat parseable numbers as numbers.
-		num, err := strconv.ParseInt(alias.String(), 0, 0)
-		if err == nil {
-			return Num(num), nil
-		}
-
-		// Otherwise, check if it's a saved alias.
 		if reg, ok := s.nums[alias.String()]; ok {
 			return reg, nil
 		}
+		for c := s; c != nil; c = c.outer {
+			if num, ok := c.consts[alias.String()]; ok {
+				return num, nil
+			}
+		}
+	case frontend.NumLit:
+		return Num(alias.Value()), nil
 	}
 	return nil, errors.Undefined(alias)
 }
 
-func (s *scope) define(name string, cmd Cmd) {
-	delete(s.cmds, name)
-	s.cmds[name] = cmd
+// Defines a procedure, which can't share its name with another procedure of
+// the same scope.
+func (s *scope) define(alias frontend.CmdAlias, cmd Cmd) error {
+	if _, ok := s.cmds[alias.String()]; ok {
+		return errors.Redefined(alias)
+	}
+	s.cmds[alias.String()] = cmd
+	return nil
+}
+
+// Defines a register alias, which can't share its name with another register
+// alias or a reg param of the same scope.
+func (s *scope) defineReg(alias frontend.RegAlias, reg Reg) error {
+	if _, ok := s.regs[alias.String()]; ok {
+		return errors.Redefined(alias)
+	}
+	s.regs[alias.String()] = reg
+	return nil
+}
+
+// Defines a constant, which can't share its name with another constant or a
+// num param of the same scope.
+func (s *scope) defineConst(alias frontend.NumAlias, num Num) error {
+	_, isParam := s.nums[alias.String()]
+	_, isConst := s.consts[alias.String()]
+	if isParam || isConst {
+		return errors.Redefined(alias)
+	}
+	s.consts[alias.String()] = num
+	return nil
 }
 
 // Checks args for proper typing according to params. If type checking succeeds,
@@ -147,12 +193,20 @@ func (s *scope) typecheck(args []frontend.Alias, params []Psuedo) ([]Psuedo, err
 					return nil, errors.Undefined(arg)
 				}
 				out[i] = psuedo
+			case frontend.NumAlias:
+				// Register params could write to num params.
+				if _, ok := s.nums[arg.String()]; ok {
+					return nil, errors.New(
+						"num param %s is read-only, so it can't be passed as a register", arg,
+					)
+				}
+				return nil, errors.TypeMismatch(param, arg)
 			default:
 				return nil, errors.TypeMismatch(param, arg)
 			}
 		case Num:
 			switch arg := args[i].(type) {
-			case frontend.RegAlias, frontend.NumAlias:
+			case frontend.RegAlias, frontend.NumAlias, frontend.NumLit:
 				psuedo, err := s.lookup(args[i])
 				if err != nil {
 					return nil, errors.Undefined(arg)
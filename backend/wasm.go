@@ -0,0 +1,331 @@
+package backend
+
+import (
+	"bytes"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Layout of the generated module. Registers are mutable i64 globals 0..7 and
+// the global after them is the stack pointer into linear memory, which starts
+// at the top of memory and grows down in 8-byte slots.
+const (
+	wasmPages    = 16
+	wasmPageSize = 1 << 16
+)
+
+// Value types, section ids and opcodes used by the encoder.
+const (
+	wasmI32  byte = 0x7f
+	wasmI64  byte = 0x7e
+	wasmVoid byte = 0x40
+
+	wasmSecType     byte = 1
+	wasmSecFunction byte = 3
+	wasmSecMemory   byte = 5
+	wasmSecGlobal   byte = 6
+	wasmSecExport   byte = 7
+	wasmSecCode     byte = 10
+
+	wasmUnreachable byte = 0x00
+	wasmBlock       byte = 0x02
+	wasmLoop        byte = 0x03
+	wasmIf          byte = 0x04
+	wasmEnd         byte = 0x0b
+	wasmBr          byte = 0x0c
+	wasmBrTable     byte = 0x0e
+	wasmReturn      byte = 0x0f
+	wasmCall        byte = 0x10
+	wasmLocalGet    byte = 0x20
+	wasmLocalSet    byte = 0x21
+	wasmGlobalGet   byte = 0x23
+	wasmGlobalSet   byte = 0x24
+	wasmI64Load     byte = 0x29
+	wasmI64Store    byte = 0x37
+	wasmI32Const    byte = 0x41
+	wasmI64Const    byte = 0x42
+	wasmI64Ne       byte = 0x52
+	wasmI32Add      byte = 0x6a
+	wasmI32Sub      byte = 0x6b
+	wasmI64Add      byte = 0x7c
+	wasmI64Sub      byte = 0x7d
+)
+
+func init() {
+	Register(target{
+		name:  "wasm",
+		regs:  8,
+		args:  6,
+		lower: lowerWasm,
+	})
+}
+
+// Lowers psuedo-instructions to a binary WebAssembly module. The top-level
+// code becomes the exported function "main", which returns register 0, and
+// every other procedure becomes a function of its own so that CALL_I is a
+// real call.
+//
+// Wasm only has structured control flow, so each function runs its body in a
+// dispatch loop over a local program counter: the code of instruction k
+// follows the end of the k-th of a stack of nested blocks, and a br_table at
+// the innermost block enters at the current counter. Jumps set the counter
+// and branch back to the loop.
+func lowerWasm(psuedo []Ins) ([]byte, error) {
+	procs, err := Procs(psuedo)
+	if err != nil {
+		return nil, err
+	}
+	funcs := make(map[Num]int, len(procs))
+	for i, p := range procs {
+		funcs[p.Addr] = i
+	}
+
+	var b bytes.Buffer
+	b.Write([]byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00})
+
+	// Type 0 is a procedure, type 1 is main.
+	wasmSection(&b, wasmSecType, 2, []byte{
+		0x60, 0, 0,
+		0x60, 0, 1, wasmI64,
+	})
+
+	var fns []byte
+	for i := range procs {
+		if i == 0 {
+			fns = append(fns, 1)
+		} else {
+			fns = append(fns, 0)
+		}
+	}
+	wasmSection(&b, wasmSecFunction, len(procs), fns)
+
+	wasmSection(&b, wasmSecMemory, 1, []byte{0x00, wasmPages})
+
+	var globals []byte
+	for r := 0; r < MaxRegCount; r++ {
+		globals = append(globals, wasmI64, 0x01, wasmI64Const, 0x00, wasmEnd)
+	}
+	globals = append(globals, wasmI32, 0x01, wasmI32Const)
+	globals = append(globals, sleb(wasmPages*wasmPageSize)...)
+	globals = append(globals, wasmEnd)
+	wasmSection(&b, wasmSecGlobal, MaxRegCount+1, globals)
+
+	export := append(uleb(len("main")), "main"...)
+	export = append(export, 0x00, 0x00) // function 0
+	wasmSection(&b, wasmSecExport, 1, export)
+
+	var code []byte
+	for i, p := range procs {
+		body, err := wasmFunc(psuedo, p, i == 0, funcs)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, uleb(len(body))...)
+		code = append(code, body...)
+	}
+	wasmSection(&b, wasmSecCode, len(procs), code)
+
+	return b.Bytes(), nil
+}
+
+// Encodes the body of the function for a procedure.
+func wasmFunc(psuedo []Ins, p Proc, main bool, funcs map[Num]int) ([]byte, error) {
+	var (
+		body = []byte{0x01, 0x01, wasmI32} // one i32 local: the counter
+		m    = int(p.End - p.Addr)
+		pc   = byte(0)
+		sp   = uleb(MaxRegCount)
+	)
+
+	// Leaves register 0 on the stack for main.
+	ret := []byte{wasmReturn}
+	if main {
+		ret = append([]byte{wasmGlobalGet, 0x00}, ret...)
+	}
+
+	body = append(body, wasmLoop, wasmVoid)
+	for k := 0; k <= m; k++ {
+		body = append(body, wasmBlock, wasmVoid)
+	}
+	body = append(body, wasmLocalGet, pc, wasmBrTable)
+	body = append(body, uleb(m)...)
+	for k := 0; k <= m; k++ {
+		body = append(body, uleb(k)...)
+	}
+	body = append(body, wasmEnd)
+
+	for k := 0; k < m; k++ {
+		addr := p.Addr + Num(k)
+		ins := psuedo[addr]
+
+		// Sets the counter to a jump target and branches to the loop, from
+		// within extra nested blocks. Only the blocks of later instructions
+		// enclose the code of instruction k, so the loop is m-k labels out.
+		jump := func(operand, extra int) ([]byte, error) {
+			to, err := insAddr(ins, operand)
+			if err != nil {
+				return nil, err
+			}
+			if !p.Contains(to) {
+				return nil, errors.New("jump out of procedure at %d", p.Addr)
+			}
+			out := append([]byte{wasmI32Const}, sleb(int64(to-p.Addr))...)
+			out = append(out, wasmLocalSet, pc, wasmBr)
+			return append(out, uleb(m-k+extra)...), nil
+		}
+
+		var (
+			enc []byte
+			err error
+		)
+		switch ins.Op {
+		case MoveI:
+			var n Num
+			var r Reg
+			if n, r, err = numReg(ins); err == nil {
+				enc = append([]byte{wasmI64Const}, sleb(int64(n))...)
+				enc = append(enc, wasmGlobalSet, byte(r))
+			}
+		case MoveR:
+			var src, dst Reg
+			if src, dst, err = regReg(ins); err == nil {
+				enc = []byte{wasmGlobalGet, byte(src), wasmGlobalSet, byte(dst)}
+			}
+		case AddR, SubR:
+			var src, dst Reg
+			if src, dst, err = regReg(ins); err == nil {
+				op := map[Opcode]byte{AddR: wasmI64Add, SubR: wasmI64Sub}[ins.Op]
+				enc = []byte{
+					wasmGlobalGet, byte(dst), wasmGlobalGet, byte(src), op,
+					wasmGlobalSet, byte(dst),
+				}
+			}
+		case AddI, SubI:
+			var n Num
+			var r Reg
+			if n, r, err = numReg(ins); err == nil {
+				op := map[Opcode]byte{AddI: wasmI64Add, SubI: wasmI64Sub}[ins.Op]
+				enc = []byte{wasmGlobalGet, byte(r), wasmI64Const}
+				enc = append(enc, sleb(int64(n))...)
+				enc = append(enc, op, wasmGlobalSet, byte(r))
+			}
+		case BneI, BneR:
+			if ins.Op == BneI {
+				var n Num
+				var r Reg
+				if n, r, err = numReg(ins); err == nil {
+					enc = append([]byte{wasmI64Const}, sleb(int64(n))...)
+					enc = append(enc, wasmGlobalGet, byte(r))
+				}
+			} else {
+				var r0, r1 Reg
+				if r0, r1, err = regReg(ins); err == nil {
+					enc = []byte{wasmGlobalGet, byte(r0), wasmGlobalGet, byte(r1)}
+				}
+			}
+			var br []byte
+			if err == nil {
+				br, err = jump(2, 1)
+			}
+			enc = append(enc, wasmI64Ne, wasmIf, wasmVoid)
+			enc = append(enc, br...)
+			enc = append(enc, wasmEnd)
+		case JumpI:
+			enc, err = jump(0, 0)
+		case CallI:
+			var to Num
+			if to, err = insAddr(ins, 0); err == nil {
+				enc = append([]byte{wasmCall}, uleb(funcs[to])...)
+			}
+		case TailI:
+			var to Num
+			if to, err = insAddr(ins, 0); err == nil {
+				enc = append([]byte{wasmCall}, uleb(funcs[to])...)
+				enc = append(enc, ret...)
+			}
+		case Ret:
+			enc = ret
+		case PushI, PushR:
+			var val []byte
+			if ins.Op == PushI {
+				var n Num
+				if n, err = insNum(ins, 0); err == nil {
+					val = append([]byte{wasmI64Const}, sleb(int64(n))...)
+				}
+			} else {
+				var r Reg
+				if r, err = insReg(ins, 0); err == nil {
+					val = []byte{wasmGlobalGet, byte(r)}
+				}
+			}
+			enc = append([]byte{wasmGlobalGet}, sp...)
+			enc = append(enc, wasmI32Const, 8, wasmI32Sub, wasmGlobalSet)
+			enc = append(enc, sp...)
+			enc = append(enc, wasmGlobalGet)
+			enc = append(enc, sp...)
+			enc = append(enc, val...)
+			enc = append(enc, wasmI64Store, 0x03, 0x00)
+		case PopR:
+			var r Reg
+			if r, err = insReg(ins, 0); err == nil {
+				enc = append([]byte{wasmGlobalGet}, sp...)
+				enc = append(enc, wasmI64Load, 0x03, 0x00, wasmGlobalSet, byte(r))
+				enc = append(enc, wasmGlobalGet)
+				enc = append(enc, sp...)
+				enc = append(enc, wasmI32Const, 8, wasmI32Add, wasmGlobalSet)
+				enc = append(enc, sp...)
+			}
+		default:
+			err = errors.Unsupported("%s instructions", ins.Op)
+		}
+		if err != nil {
+			return nil, errors.New("ins %d (%s): %s", addr, ins, err)
+		}
+
+		body = append(body, enc...)
+		body = append(body, wasmEnd) // block k+1
+	}
+
+	// Control reached the end of the body.
+	body = append(body, ret...)
+	body = append(body, wasmEnd) // loop
+	if main {
+		body = append(body, wasmUnreachable)
+	}
+	return append(body, wasmEnd), nil
+}
+
+// Writes a section holding a vector of count items.
+func wasmSection(b *bytes.Buffer, id byte, count int, items []byte) {
+	content := append(uleb(count), items...)
+	b.WriteByte(id)
+	b.Write(uleb(len(content)))
+	b.Write(content)
+}
+
+// Unsigned LEB128.
+func uleb(n int) []byte {
+	var out []byte
+	u := uint64(n)
+	for {
+		c := byte(u & 0x7f)
+		if u >>= 7; u != 0 {
+			out = append(out, c|0x80)
+		} else {
+			return append(out, c)
+		}
+	}
+}
+
+// Signed LEB128.
+func sleb(n int64) []byte {
+	var out []byte
+	for {
+		c := byte(n & 0x7f)
+		n >>= 7
+		if (n == 0 && c&0x40 == 0) || (n == -1 && c&0x40 != 0) {
+			return append(out, c)
+		}
+		out = append(out, c|0x80)
+	}
+}
@@ -37,6 +37,30 @@ func configTargetArchitecture(short, long string) {
 	}
 }
 
+func configEmit(emit string) {
+	EmitFlag = emit
+}
+
+func configGoPackage(name string) {
+	backend.GoPackageFlag = name
+}
+
+func configOutput(short, long string) {
+	if long == "" {
+		OutputFlag = short
+	} else {
+		OutputFlag = long
+	}
+}
+
+func configCompileOnly(compile bool) {
+	CompileOnlyFlag = compile
+}
+
+func configListTargets(list bool) {
+	ListTargetsFlag = list
+}
+
 func configHelp(short, long bool) {
 	HelpFlag = short || long
 }
@@ -42,3 +42,7 @@ func CountMismatch(expected, found int) error {
 		expected, found,
 	))
 }
+
+func Redefined(t Textual) error {
+	return New("redefined %s: %s", t.Type(), t)
+}
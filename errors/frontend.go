@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"math"
 )
 
 // Flag-configurables.
@@ -38,3 +39,11 @@ func DebugParser(verbosity int, prefix bool, format string, a ...interface{}) {
 func UnrecognizedInput(rn rune) error {
 	return New(fmt.Sprintf("Unrecognized input: %s\n", Repr(rn)))
 }
+
+func BadNumLit(lit string) error {
+	return New("bad number literal: #%s", lit)
+}
+
+func NumLitOutOfRange(lit string) error {
+	return New("number literal out of range: #%s (must be within [%d, %d])", lit, math.MinInt64, int64(math.MaxInt64))
+}
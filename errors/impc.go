@@ -18,3 +18,7 @@ func Ok(filename string) {
 func BadSourceFile(filename string, err error) error {
 	return New(fmt.Sprintf("error opening %s: %v", filename, err))
 }
+
+func BadOutputFile(filename string, err error) error {
+	return New("error writing %s: %v", filename, err)
+}
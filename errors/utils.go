@@ -60,7 +60,16 @@ func Indent(s string) string {
 	return PrefixLines(s, "\t")
 }
 
+// Implemented by Textual objects that can come from another file than the one
+// being compiled, in which case File returns its name.
+type Filed interface {
+	File() string
+}
+
 // Wraps an error with the context of a Textual object.
 func Wrap(err error, t Textual) error {
+	if f, ok := t.(Filed); ok && f.File() != "" {
+		return New("%s at %s:%d (%s): %s", t.Type(), f.File(), t.Pos(), t, err)
+	}
 	return New(fmt.Sprintf("%s at %d (%s): %s", t.Type(), t.Pos(), t, err))
 }
@@ -0,0 +1,47 @@
+	.text
+	.globl _start
+_start:
+.L0:		// MOVE_I 1 1
+	movz x20, #1
+.L1:		// JUMP_I 6
+	b .L6
+.L2:		// MOVE_R 0 1
+	mov x20, x19
+.L3:		// MOVE_R 1 2
+	mov x21, x20
+.L4:		// MOVE_R 2 3
+	mov x22, x21
+.L5:		// RET
+	ret
+.L6:		// PUSH_R 1
+	str x20, [sp, #-16]!
+.L7:		// MOVE_R 0 1
+	mov x20, x19
+.L8:		// POP_R 0
+	ldr x19, [sp], #16
+.L9:		// PUSH_R 3
+	str x22, [sp, #-16]!
+.L10:		// MOVE_R 2 3
+	mov x22, x21
+.L11:		// POP_R 2
+	ldr x21, [sp], #16
+.L12:		// CALL_I 2
+	str x30, [sp, #-16]!
+	bl .L2
+	ldr x30, [sp], #16
+.L13:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L14:		// MOVE_R 3 2
+	mov x21, x22
+.L15:		// POP_R 3
+	ldr x22, [sp], #16
+.L16:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L17:		// MOVE_R 1 0
+	mov x19, x20
+.L18:		// POP_R 1
+	ldr x20, [sp], #16
+.L19:
+	mov x0, x19
+	mov x8, #93
+	svc #0
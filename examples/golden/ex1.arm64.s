@@ -0,0 +1,33 @@
+	.text
+	.globl _start
+_start:
+.L0:		// JUMP_I 4
+	b .L4
+.L1:		// MOVE_R 0 1
+	mov x20, x19
+.L2:		// MOVE_I 32 2
+	movz x21, #32
+.L3:		// RET
+	ret
+.L4:		// PUSH_R 1
+	str x20, [sp, #-16]!
+.L5:		// MOVE_R 2 1
+	mov x20, x21
+.L6:		// MOVE_R 0 2
+	mov x21, x19
+.L7:		// MOVE_I 123 0
+	movz x19, #123
+.L8:		// CALL_I 1
+	str x30, [sp, #-16]!
+	bl .L1
+	ldr x30, [sp], #16
+.L9:		// MOVE_R 2 0
+	mov x19, x21
+.L10:		// MOVE_R 1 2
+	mov x21, x20
+.L11:		// POP_R 1
+	ldr x20, [sp], #16
+.L12:
+	mov x0, x19
+	mov x8, #93
+	svc #0
@@ -0,0 +1,120 @@
+; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [65536 x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %v) {
+entry:
+  %sp = load i64, ptr @sp
+  %full = icmp uge i64 %sp, 65536
+  br i1 %full, label %trap, label %ok
+ok:
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %sp
+  store i64 %v, ptr %slot
+  %next = add i64 %sp, 1
+  store i64 %next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %sp = load i64, ptr @sp
+  %empty = icmp eq i64 %sp, 0
+  br i1 %empty, label %trap, label %ok
+ok:
+  %next = sub i64 %sp, 1
+  store i64 %next, ptr @sp
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %next
+  %v = load i64, ptr %slot
+  ret i64 %v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %r = alloca [8 x i64]
+  store [8 x i64] zeroinitializer, ptr %r
+  %ret = call i64 @imp_main(ptr %r)
+  %status = trunc i64 %ret to i32
+  ret i32 %status
+}
+
+define internal i64 @imp_main(ptr %r) {
+entry:
+  br label %L0
+L0: ; JUMP_I 4
+  br label %L4
+L4: ; PUSH_R 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t2 = load i64, ptr %t1
+  call void @push(i64 %t2)
+  br label %L5
+L5: ; MOVE_R 2 1
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t4 = load i64, ptr %t3
+  %t5 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t4, ptr %t5
+  br label %L6
+L6: ; MOVE_R 0 2
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t7 = load i64, ptr %t6
+  %t8 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t7, ptr %t8
+  br label %L7
+L7: ; MOVE_I 123 0
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 123, ptr %t9
+  br label %L8
+L8: ; CALL_I 1
+  call void @proc1(ptr %r)
+  br label %L9
+L9: ; MOVE_R 2 0
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t11 = load i64, ptr %t10
+  %t12 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t11, ptr %t12
+  br label %L10
+L10: ; MOVE_R 1 2
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t14 = load i64, ptr %t13
+  %t15 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t14, ptr %t15
+  br label %L11
+L11: ; POP_R 1
+  %t16 = call i64 @pop()
+  %t17 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t16, ptr %t17
+  br label %L12
+L12:
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t19 = load i64, ptr %t18
+  ret i64 %t19
+}
+
+define internal void @proc1(ptr %r) {
+entry:
+  br label %L1
+L1: ; MOVE_R 0 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t2 = load i64, ptr %t1
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t2, ptr %t3
+  br label %L2
+L2: ; MOVE_I 32 2
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 32, ptr %t4
+  br label %L3
+L3: ; RET
+  ret void
+L4:
+  ret void
+}
@@ -0,0 +1,75 @@
+	.text
+	.globl _start
+_start:
+.L0:		// JUMP_I 5
+	b .L5
+.L1:		// MOVE_R 0 1
+	mov x20, x19
+.L2:		// MOVE_R 1 2
+	mov x21, x20
+.L3:		// MOVE_R 2 3
+	mov x22, x21
+.L4:		// RET
+	ret
+.L5:		// MOVE_I 2 1
+	movz x20, #2
+.L6:		// PUSH_R 1
+	str x20, [sp, #-16]!
+.L7:		// MOVE_R 0 1
+	mov x20, x19
+.L8:		// POP_R 0
+	ldr x19, [sp], #16
+.L9:		// PUSH_R 3
+	str x22, [sp, #-16]!
+.L10:		// MOVE_R 2 3
+	mov x22, x21
+.L11:		// POP_R 2
+	ldr x21, [sp], #16
+.L12:		// CALL_I 1
+	str x30, [sp, #-16]!
+	bl .L1
+	ldr x30, [sp], #16
+.L13:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L14:		// MOVE_R 3 2
+	mov x21, x22
+.L15:		// POP_R 3
+	ldr x22, [sp], #16
+.L16:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L17:		// MOVE_R 1 0
+	mov x19, x20
+.L18:		// POP_R 1
+	ldr x20, [sp], #16
+.L19:		// JUMP_I 23
+	b .L23
+.L20:		// MOVE_R 0 2
+	mov x21, x19
+.L21:		// MOVE_R 1 0
+	mov x19, x20
+.L22:		// RET
+	ret
+.L23:		// MOVE_I 3 2
+	movz x21, #3
+.L24:		// MOVE_I 4 1
+	movz x20, #4
+.L25:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L26:		// MOVE_R 0 2
+	mov x21, x19
+.L27:		// POP_R 0
+	ldr x19, [sp], #16
+.L28:		// CALL_I 20
+	str x30, [sp, #-16]!
+	bl .L20
+	ldr x30, [sp], #16
+.L29:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L30:		// MOVE_R 2 0
+	mov x19, x21
+.L31:		// POP_R 2
+	ldr x21, [sp], #16
+.L32:
+	mov x0, x19
+	mov x8, #93
+	svc #0
@@ -0,0 +1,224 @@
+; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [65536 x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %v) {
+entry:
+  %sp = load i64, ptr @sp
+  %full = icmp uge i64 %sp, 65536
+  br i1 %full, label %trap, label %ok
+ok:
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %sp
+  store i64 %v, ptr %slot
+  %next = add i64 %sp, 1
+  store i64 %next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %sp = load i64, ptr @sp
+  %empty = icmp eq i64 %sp, 0
+  br i1 %empty, label %trap, label %ok
+ok:
+  %next = sub i64 %sp, 1
+  store i64 %next, ptr @sp
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %next
+  %v = load i64, ptr %slot
+  ret i64 %v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %r = alloca [8 x i64]
+  store [8 x i64] zeroinitializer, ptr %r
+  %ret = call i64 @imp_main(ptr %r)
+  %status = trunc i64 %ret to i32
+  ret i32 %status
+}
+
+define internal i64 @imp_main(ptr %r) {
+entry:
+  br label %L0
+L0: ; JUMP_I 5
+  br label %L5
+L5: ; MOVE_I 2 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 2, ptr %t1
+  br label %L6
+L6: ; PUSH_R 1
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t3 = load i64, ptr %t2
+  call void @push(i64 %t3)
+  br label %L7
+L7: ; MOVE_R 0 1
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t5, ptr %t6
+  br label %L8
+L8: ; POP_R 0
+  %t7 = call i64 @pop()
+  %t8 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t7, ptr %t8
+  br label %L9
+L9: ; PUSH_R 3
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t10 = load i64, ptr %t9
+  call void @push(i64 %t10)
+  br label %L10
+L10: ; MOVE_R 2 3
+  %t11 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t12 = load i64, ptr %t11
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t12, ptr %t13
+  br label %L11
+L11: ; POP_R 2
+  %t14 = call i64 @pop()
+  %t15 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t14, ptr %t15
+  br label %L12
+L12: ; CALL_I 1
+  call void @proc1(ptr %r)
+  br label %L13
+L13: ; PUSH_R 2
+  %t16 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t17 = load i64, ptr %t16
+  call void @push(i64 %t17)
+  br label %L14
+L14: ; MOVE_R 3 2
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t19 = load i64, ptr %t18
+  %t20 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t19, ptr %t20
+  br label %L15
+L15: ; POP_R 3
+  %t21 = call i64 @pop()
+  %t22 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t21, ptr %t22
+  br label %L16
+L16: ; PUSH_R 0
+  %t23 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t24 = load i64, ptr %t23
+  call void @push(i64 %t24)
+  br label %L17
+L17: ; MOVE_R 1 0
+  %t25 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t26 = load i64, ptr %t25
+  %t27 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t26, ptr %t27
+  br label %L18
+L18: ; POP_R 1
+  %t28 = call i64 @pop()
+  %t29 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t28, ptr %t29
+  br label %L19
+L19: ; JUMP_I 23
+  br label %L23
+L23: ; MOVE_I 3 2
+  %t30 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 3, ptr %t30
+  br label %L24
+L24: ; MOVE_I 4 1
+  %t31 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 4, ptr %t31
+  br label %L25
+L25: ; PUSH_R 2
+  %t32 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t33 = load i64, ptr %t32
+  call void @push(i64 %t33)
+  br label %L26
+L26: ; MOVE_R 0 2
+  %t34 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t35 = load i64, ptr %t34
+  %t36 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t35, ptr %t36
+  br label %L27
+L27: ; POP_R 0
+  %t37 = call i64 @pop()
+  %t38 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t37, ptr %t38
+  br label %L28
+L28: ; CALL_I 20
+  call void @proc20(ptr %r)
+  br label %L29
+L29: ; PUSH_R 0
+  %t39 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t40 = load i64, ptr %t39
+  call void @push(i64 %t40)
+  br label %L30
+L30: ; MOVE_R 2 0
+  %t41 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t42 = load i64, ptr %t41
+  %t43 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t42, ptr %t43
+  br label %L31
+L31: ; POP_R 2
+  %t44 = call i64 @pop()
+  %t45 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t44, ptr %t45
+  br label %L32
+L32:
+  %t46 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t47 = load i64, ptr %t46
+  ret i64 %t47
+}
+
+define internal void @proc1(ptr %r) {
+entry:
+  br label %L1
+L1: ; MOVE_R 0 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t2 = load i64, ptr %t1
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t2, ptr %t3
+  br label %L2
+L2: ; MOVE_R 1 2
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t5, ptr %t6
+  br label %L3
+L3: ; MOVE_R 2 3
+  %t7 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t8 = load i64, ptr %t7
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t8, ptr %t9
+  br label %L4
+L4: ; RET
+  ret void
+L5:
+  ret void
+}
+
+define internal void @proc20(ptr %r) {
+entry:
+  br label %L20
+L20: ; MOVE_R 0 2
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t2 = load i64, ptr %t1
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t2, ptr %t3
+  br label %L21
+L21: ; MOVE_R 1 0
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t5, ptr %t6
+  br label %L22
+L22: ; RET
+  ret void
+L23:
+  ret void
+}
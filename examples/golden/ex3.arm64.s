@@ -0,0 +1,15 @@
+	.text
+	.globl _start
+_start:
+.L0:		// JUMP_I 2
+	b .L2
+.L1:		// RET
+	ret
+.L2:		// CALL_I 1
+	str x30, [sp, #-16]!
+	bl .L1
+	ldr x30, [sp], #16
+.L3:
+	mov x0, x19
+	mov x8, #93
+	svc #0
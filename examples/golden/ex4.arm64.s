@@ -0,0 +1,37 @@
+	.text
+	.globl _start
+_start:
+.L0:		// JUMP_I 12
+	b .L12
+.L1:		// JUMP_I 10
+	b .L10
+.L2:		// JUMP_I 8
+	b .L8
+.L3:		// JUMP_I 6
+	b .L6
+.L4:		// MOVE_R 0 1
+	mov x20, x19
+.L5:		// RET
+	ret
+.L6:		// TAIL_I 4
+	b .L4
+.L7:		// RET
+	ret
+.L8:		// TAIL_I 3
+	b .L3
+.L9:		// RET
+	ret
+.L10:		// TAIL_I 2
+	b .L2
+.L11:		// RET
+	ret
+.L12:		// MOVE_I 1 0
+	movz x19, #1
+.L13:		// CALL_I 1
+	str x30, [sp, #-16]!
+	bl .L1
+	ldr x30, [sp], #16
+.L14:
+	mov x0, x19
+	mov x8, #93
+	svc #0
@@ -0,0 +1,124 @@
+; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [65536 x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %v) {
+entry:
+  %sp = load i64, ptr @sp
+  %full = icmp uge i64 %sp, 65536
+  br i1 %full, label %trap, label %ok
+ok:
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %sp
+  store i64 %v, ptr %slot
+  %next = add i64 %sp, 1
+  store i64 %next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %sp = load i64, ptr @sp
+  %empty = icmp eq i64 %sp, 0
+  br i1 %empty, label %trap, label %ok
+ok:
+  %next = sub i64 %sp, 1
+  store i64 %next, ptr @sp
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %next
+  %v = load i64, ptr %slot
+  ret i64 %v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %r = alloca [8 x i64]
+  store [8 x i64] zeroinitializer, ptr %r
+  %ret = call i64 @imp_main(ptr %r)
+  %status = trunc i64 %ret to i32
+  ret i32 %status
+}
+
+define internal i64 @imp_main(ptr %r) {
+entry:
+  br label %L0
+L0: ; JUMP_I 12
+  br label %L12
+L12: ; MOVE_I 1 0
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 1, ptr %t1
+  br label %L13
+L13: ; CALL_I 1
+  call void @proc1(ptr %r)
+  br label %L14
+L14:
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t3 = load i64, ptr %t2
+  ret i64 %t3
+}
+
+define internal void @proc1(ptr %r) {
+entry:
+  br label %L1
+L1: ; JUMP_I 10
+  br label %L10
+L10: ; TAIL_I 2
+  musttail call void @proc2(ptr %r)
+  ret void
+L11: ; RET
+  ret void
+L12:
+  ret void
+}
+
+define internal void @proc2(ptr %r) {
+entry:
+  br label %L2
+L2: ; JUMP_I 8
+  br label %L8
+L8: ; TAIL_I 3
+  musttail call void @proc3(ptr %r)
+  ret void
+L9: ; RET
+  ret void
+L10:
+  ret void
+}
+
+define internal void @proc3(ptr %r) {
+entry:
+  br label %L3
+L3: ; JUMP_I 6
+  br label %L6
+L6: ; TAIL_I 4
+  musttail call void @proc4(ptr %r)
+  ret void
+L7: ; RET
+  ret void
+L8:
+  ret void
+}
+
+define internal void @proc4(ptr %r) {
+entry:
+  br label %L4
+L4: ; MOVE_R 0 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t2 = load i64, ptr %t1
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t2, ptr %t3
+  br label %L5
+L5: ; RET
+  ret void
+L6:
+  ret void
+}
@@ -0,0 +1,136 @@
+	.text
+	.globl _start
+_start:
+.L0:		// JUMP_I 17
+	b .L17
+.L1:		// BNE_I 0 0 3
+	cmp x19, #0
+	b.ne .L3
+.L2:		// RET
+	ret
+.L3:		// BNE_I 1 0 5
+	cmp x19, #1
+	b.ne .L5
+.L4:		// RET
+	ret
+.L5:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L6:		// MOVE_R 0 1
+	mov x20, x19
+.L7:		// POP_R 0
+	ldr x19, [sp], #16
+.L8:		// CALL_I 42
+	str x30, [sp, #-16]!
+	bl .L42
+	ldr x30, [sp], #16
+.L9:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L10:		// MOVE_R 1 0
+	mov x19, x20
+.L11:		// MOVE_R 2 1
+	mov x20, x21
+.L12:		// POP_R 2
+	ldr x21, [sp], #16
+.L13:		// MOVE_R 1 2
+	mov x21, x20
+.L14:		// SUB_I 1 0
+	sub x19, x19, #1
+.L15:		// JUMP_I 1
+	b .L1
+.L16:		// RET
+	ret
+.L17:		// JUMP_I 34
+	b .L34
+.L18:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L19:		// PUSH_R 3
+	str x22, [sp, #-16]!
+.L20:		// MOVE_I 1 1
+	movz x20, #1
+.L21:		// MOVE_R 0 2
+	mov x21, x19
+.L22:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L23:		// MOVE_R 2 0
+	mov x19, x21
+.L24:		// MOVE_R 1 2
+	mov x21, x20
+.L25:		// MOVE_R 3 1
+	mov x20, x22
+.L26:		// CALL_I 1
+	str x30, [sp, #-16]!
+	bl .L1
+	ldr x30, [sp], #16
+.L27:		// MOVE_R 1 3
+	mov x22, x20
+.L28:		// MOVE_R 2 1
+	mov x20, x21
+.L29:		// MOVE_R 0 2
+	mov x21, x19
+.L30:		// POP_R 0
+	ldr x19, [sp], #16
+.L31:		// POP_R 3
+	ldr x22, [sp], #16
+.L32:		// POP_R 2
+	ldr x21, [sp], #16
+.L33:		// RET
+	ret
+.L34:		// JUMP_I 41
+	b .L41
+.L35:		// BNE_I 0 0 37
+	cmp x19, #0
+	b.ne .L37
+.L36:		// RET
+	ret
+.L37:		// ADD_R 1 2
+	add x21, x21, x20
+.L38:		// SUB_I 1 0
+	sub x19, x19, #1
+.L39:		// JUMP_I 35
+	b .L35
+.L40:		// RET
+	ret
+.L41:		// JUMP_I 52
+	b .L52
+.L42:		// PUSH_R 3
+	str x22, [sp, #-16]!
+.L43:		// MOVE_I 0 2
+	movz x21, #0
+.L44:		// MOVE_R 0 3
+	mov x22, x19
+.L45:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L46:		// MOVE_R 3 0
+	mov x19, x22
+.L47:		// CALL_I 35
+	str x30, [sp, #-16]!
+	bl .L35
+	ldr x30, [sp], #16
+.L48:		// MOVE_R 0 3
+	mov x22, x19
+.L49:		// POP_R 0
+	ldr x19, [sp], #16
+.L50:		// POP_R 3
+	ldr x22, [sp], #16
+.L51:		// RET
+	ret
+.L52:		// MOVE_I 4 1
+	movz x20, #4
+.L53:		// PUSH_R 1
+	str x20, [sp, #-16]!
+.L54:		// POP_R 0
+	ldr x19, [sp], #16
+.L55:		// CALL_I 18
+	str x30, [sp, #-16]!
+	bl .L18
+	ldr x30, [sp], #16
+.L56:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L57:		// MOVE_R 1 0
+	mov x19, x20
+.L58:		// POP_R 1
+	ldr x20, [sp], #16
+.L59:
+	mov x0, x19
+	mov x8, #93
+	svc #0
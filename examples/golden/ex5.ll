@@ -0,0 +1,354 @@
+; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [65536 x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %v) {
+entry:
+  %sp = load i64, ptr @sp
+  %full = icmp uge i64 %sp, 65536
+  br i1 %full, label %trap, label %ok
+ok:
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %sp
+  store i64 %v, ptr %slot
+  %next = add i64 %sp, 1
+  store i64 %next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %sp = load i64, ptr @sp
+  %empty = icmp eq i64 %sp, 0
+  br i1 %empty, label %trap, label %ok
+ok:
+  %next = sub i64 %sp, 1
+  store i64 %next, ptr @sp
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %next
+  %v = load i64, ptr %slot
+  ret i64 %v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %r = alloca [8 x i64]
+  store [8 x i64] zeroinitializer, ptr %r
+  %ret = call i64 @imp_main(ptr %r)
+  %status = trunc i64 %ret to i32
+  ret i32 %status
+}
+
+define internal i64 @imp_main(ptr %r) {
+entry:
+  br label %L0
+L0: ; JUMP_I 17
+  br label %L17
+L17: ; JUMP_I 34
+  br label %L34
+L34: ; JUMP_I 41
+  br label %L41
+L41: ; JUMP_I 52
+  br label %L52
+L52: ; MOVE_I 4 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 4, ptr %t1
+  br label %L53
+L53: ; PUSH_R 1
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t3 = load i64, ptr %t2
+  call void @push(i64 %t3)
+  br label %L54
+L54: ; POP_R 0
+  %t4 = call i64 @pop()
+  %t5 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t4, ptr %t5
+  br label %L55
+L55: ; CALL_I 18
+  call void @proc18(ptr %r)
+  br label %L56
+L56: ; PUSH_R 0
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t7 = load i64, ptr %t6
+  call void @push(i64 %t7)
+  br label %L57
+L57: ; MOVE_R 1 0
+  %t8 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t9 = load i64, ptr %t8
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t9, ptr %t10
+  br label %L58
+L58: ; POP_R 1
+  %t11 = call i64 @pop()
+  %t12 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t11, ptr %t12
+  br label %L59
+L59:
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t14 = load i64, ptr %t13
+  ret i64 %t14
+}
+
+define internal void @proc1(ptr %r) {
+entry:
+  br label %L1
+L1: ; BNE_I 0 0 3
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t2 = load i64, ptr %t1
+  %t3 = icmp ne i64 0, %t2
+  br i1 %t3, label %L3, label %L2
+L2: ; RET
+  ret void
+L3: ; BNE_I 1 0 5
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t5 = load i64, ptr %t4
+  %t6 = icmp ne i64 1, %t5
+  br i1 %t6, label %L5, label %L4
+L4: ; RET
+  ret void
+L5: ; PUSH_R 2
+  %t7 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t8 = load i64, ptr %t7
+  call void @push(i64 %t8)
+  br label %L6
+L6: ; MOVE_R 0 1
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t10 = load i64, ptr %t9
+  %t11 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t10, ptr %t11
+  br label %L7
+L7: ; POP_R 0
+  %t12 = call i64 @pop()
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t12, ptr %t13
+  br label %L8
+L8: ; CALL_I 42
+  call void @proc42(ptr %r)
+  br label %L9
+L9: ; PUSH_R 0
+  %t14 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t15 = load i64, ptr %t14
+  call void @push(i64 %t15)
+  br label %L10
+L10: ; MOVE_R 1 0
+  %t16 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t17 = load i64, ptr %t16
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t17, ptr %t18
+  br label %L11
+L11: ; MOVE_R 2 1
+  %t19 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t20 = load i64, ptr %t19
+  %t21 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t20, ptr %t21
+  br label %L12
+L12: ; POP_R 2
+  %t22 = call i64 @pop()
+  %t23 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t22, ptr %t23
+  br label %L13
+L13: ; MOVE_R 1 2
+  %t24 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t25 = load i64, ptr %t24
+  %t26 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t25, ptr %t26
+  br label %L14
+L14: ; SUB_I 1 0
+  %t27 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t28 = load i64, ptr %t27
+  %t29 = sub i64 %t28, 1
+  %t30 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t29, ptr %t30
+  br label %L15
+L15: ; JUMP_I 1
+  br label %L1
+L16: ; RET
+  ret void
+L17:
+  ret void
+}
+
+define internal void @proc18(ptr %r) {
+entry:
+  br label %L18
+L18: ; PUSH_R 2
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t2 = load i64, ptr %t1
+  call void @push(i64 %t2)
+  br label %L19
+L19: ; PUSH_R 3
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t4 = load i64, ptr %t3
+  call void @push(i64 %t4)
+  br label %L20
+L20: ; MOVE_I 1 1
+  %t5 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 1, ptr %t5
+  br label %L21
+L21: ; MOVE_R 0 2
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t7 = load i64, ptr %t6
+  %t8 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t7, ptr %t8
+  br label %L22
+L22: ; PUSH_R 0
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t10 = load i64, ptr %t9
+  call void @push(i64 %t10)
+  br label %L23
+L23: ; MOVE_R 2 0
+  %t11 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t12 = load i64, ptr %t11
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t12, ptr %t13
+  br label %L24
+L24: ; MOVE_R 1 2
+  %t14 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t15 = load i64, ptr %t14
+  %t16 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t15, ptr %t16
+  br label %L25
+L25: ; MOVE_R 3 1
+  %t17 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t18 = load i64, ptr %t17
+  %t19 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t18, ptr %t19
+  br label %L26
+L26: ; CALL_I 1
+  call void @proc1(ptr %r)
+  br label %L27
+L27: ; MOVE_R 1 3
+  %t20 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t21 = load i64, ptr %t20
+  %t22 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t21, ptr %t22
+  br label %L28
+L28: ; MOVE_R 2 1
+  %t23 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t24 = load i64, ptr %t23
+  %t25 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t24, ptr %t25
+  br label %L29
+L29: ; MOVE_R 0 2
+  %t26 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t27 = load i64, ptr %t26
+  %t28 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t27, ptr %t28
+  br label %L30
+L30: ; POP_R 0
+  %t29 = call i64 @pop()
+  %t30 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t29, ptr %t30
+  br label %L31
+L31: ; POP_R 3
+  %t31 = call i64 @pop()
+  %t32 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t31, ptr %t32
+  br label %L32
+L32: ; POP_R 2
+  %t33 = call i64 @pop()
+  %t34 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t33, ptr %t34
+  br label %L33
+L33: ; RET
+  ret void
+L34:
+  ret void
+}
+
+define internal void @proc35(ptr %r) {
+entry:
+  br label %L35
+L35: ; BNE_I 0 0 37
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t2 = load i64, ptr %t1
+  %t3 = icmp ne i64 0, %t2
+  br i1 %t3, label %L37, label %L36
+L36: ; RET
+  ret void
+L37: ; ADD_R 1 2
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t7 = load i64, ptr %t6
+  %t8 = add i64 %t7, %t5
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t8, ptr %t9
+  br label %L38
+L38: ; SUB_I 1 0
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t11 = load i64, ptr %t10
+  %t12 = sub i64 %t11, 1
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t12, ptr %t13
+  br label %L39
+L39: ; JUMP_I 35
+  br label %L35
+L40: ; RET
+  ret void
+L41:
+  ret void
+}
+
+define internal void @proc42(ptr %r) {
+entry:
+  br label %L42
+L42: ; PUSH_R 3
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t2 = load i64, ptr %t1
+  call void @push(i64 %t2)
+  br label %L43
+L43: ; MOVE_I 0 2
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 0, ptr %t3
+  br label %L44
+L44: ; MOVE_R 0 3
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t5, ptr %t6
+  br label %L45
+L45: ; PUSH_R 0
+  %t7 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t8 = load i64, ptr %t7
+  call void @push(i64 %t8)
+  br label %L46
+L46: ; MOVE_R 3 0
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t10 = load i64, ptr %t9
+  %t11 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t10, ptr %t11
+  br label %L47
+L47: ; CALL_I 35
+  call void @proc35(ptr %r)
+  br label %L48
+L48: ; MOVE_R 0 3
+  %t12 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t13 = load i64, ptr %t12
+  %t14 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t13, ptr %t14
+  br label %L49
+L49: ; POP_R 0
+  %t15 = call i64 @pop()
+  %t16 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t15, ptr %t16
+  br label %L50
+L50: ; POP_R 3
+  %t17 = call i64 @pop()
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t17, ptr %t18
+  br label %L51
+L51: ; RET
+  ret void
+L52:
+  ret void
+}
@@ -0,0 +1,29 @@
+	.text
+	.globl _start
+_start:
+.L0:		// MOVE_I 97 0
+	movz x19, #97
+.L1:		// SUB_I 32 0
+	sub x19, x19, #32
+.L2:		// ADD_I 10 0
+	add x19, x19, #10
+.L3:		// ADD_I 8 0
+	add x19, x19, #8
+.L4:		// ADD_I -1000 0
+	sub x19, x19, #1000
+.L5:		// ADD_I 1000 0
+	add x19, x19, #1000
+.L6:		// MOVE_I 4294967296 1
+	movz x20, #0
+	movk x20, #1, lsl #32
+.L7:		// ADD_I -4294967296 1
+	movz x9, #0
+	movk x9, #65535, lsl #32
+	movk x9, #65535, lsl #48
+	add x20, x20, x9
+.L8:		// ADD_R 1 0
+	add x19, x19, x20
+.L9:
+	mov x0, x19
+	mov x8, #93
+	svc #0
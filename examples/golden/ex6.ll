@@ -0,0 +1,117 @@
+; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [65536 x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %v) {
+entry:
+  %sp = load i64, ptr @sp
+  %full = icmp uge i64 %sp, 65536
+  br i1 %full, label %trap, label %ok
+ok:
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %sp
+  store i64 %v, ptr %slot
+  %next = add i64 %sp, 1
+  store i64 %next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %sp = load i64, ptr @sp
+  %empty = icmp eq i64 %sp, 0
+  br i1 %empty, label %trap, label %ok
+ok:
+  %next = sub i64 %sp, 1
+  store i64 %next, ptr @sp
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %next
+  %v = load i64, ptr %slot
+  ret i64 %v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %r = alloca [8 x i64]
+  store [8 x i64] zeroinitializer, ptr %r
+  %ret = call i64 @imp_main(ptr %r)
+  %status = trunc i64 %ret to i32
+  ret i32 %status
+}
+
+define internal i64 @imp_main(ptr %r) {
+entry:
+  br label %L0
+L0: ; MOVE_I 97 0
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 97, ptr %t1
+  br label %L1
+L1: ; SUB_I 32 0
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t3 = load i64, ptr %t2
+  %t4 = sub i64 %t3, 32
+  %t5 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t4, ptr %t5
+  br label %L2
+L2: ; ADD_I 10 0
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t7 = load i64, ptr %t6
+  %t8 = add i64 %t7, 10
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t8, ptr %t9
+  br label %L3
+L3: ; ADD_I 8 0
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t11 = load i64, ptr %t10
+  %t12 = add i64 %t11, 8
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t12, ptr %t13
+  br label %L4
+L4: ; ADD_I -1000 0
+  %t14 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t15 = load i64, ptr %t14
+  %t16 = add i64 %t15, -1000
+  %t17 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t16, ptr %t17
+  br label %L5
+L5: ; ADD_I 1000 0
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t19 = load i64, ptr %t18
+  %t20 = add i64 %t19, 1000
+  %t21 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t20, ptr %t21
+  br label %L6
+L6: ; MOVE_I 4294967296 1
+  %t22 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 4294967296, ptr %t22
+  br label %L7
+L7: ; ADD_I -4294967296 1
+  %t23 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t24 = load i64, ptr %t23
+  %t25 = add i64 %t24, -4294967296
+  %t26 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t25, ptr %t26
+  br label %L8
+L8: ; ADD_R 1 0
+  %t27 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t28 = load i64, ptr %t27
+  %t29 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t30 = load i64, ptr %t29
+  %t31 = add i64 %t30, %t28
+  %t32 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t31, ptr %t32
+  br label %L9
+L9:
+  %t33 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t34 = load i64, ptr %t33
+  ret i64 %t34
+}
@@ -0,0 +1,66 @@
+	.text
+	.globl _start
+_start:
+.L0:		// JUMP_I 7
+	b .L7
+.L1:		// BNE_I 0 1 3
+	cmp x20, #0
+	b.ne .L3
+.L2:		// RET
+	ret
+.L3:		// ADD_R 0 2
+	add x21, x21, x19
+.L4:		// SUB_I 1 1
+	sub x20, x20, #1
+.L5:		// JUMP_I 1
+	b .L1
+.L6:		// RET
+	ret
+.L7:		// JUMP_I 17
+	b .L17
+.L8:		// PUSH_R 3
+	str x22, [sp, #-16]!
+.L9:		// MOVE_R 1 3
+	mov x22, x20
+.L10:		// PUSH_R 1
+	str x20, [sp, #-16]!
+.L11:		// MOVE_R 3 1
+	mov x20, x22
+.L12:		// CALL_I 1
+	str x30, [sp, #-16]!
+	bl .L1
+	ldr x30, [sp], #16
+.L13:		// MOVE_R 1 3
+	mov x22, x20
+.L14:		// POP_R 1
+	ldr x20, [sp], #16
+.L15:		// POP_R 3
+	ldr x22, [sp], #16
+.L16:		// RET
+	ret
+.L17:		// MOVE_I 6 1
+	movz x20, #6
+.L18:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L19:		// MOVE_R 0 2
+	mov x21, x19
+.L20:		// MOVE_R 1 0
+	mov x19, x20
+.L21:		// MOVE_I 7 1
+	movz x20, #7
+.L22:		// CALL_I 8
+	str x30, [sp, #-16]!
+	bl .L8
+	ldr x30, [sp], #16
+.L23:		// MOVE_R 0 1
+	mov x20, x19
+.L24:		// MOVE_R 2 0
+	mov x19, x21
+.L25:		// POP_R 2
+	ldr x21, [sp], #16
+.L26:		// ADD_R 1 0
+	add x19, x19, x20
+.L27:
+	mov x0, x19
+	mov x8, #93
+	svc #0
@@ -0,0 +1,200 @@
+; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [65536 x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %v) {
+entry:
+  %sp = load i64, ptr @sp
+  %full = icmp uge i64 %sp, 65536
+  br i1 %full, label %trap, label %ok
+ok:
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %sp
+  store i64 %v, ptr %slot
+  %next = add i64 %sp, 1
+  store i64 %next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %sp = load i64, ptr @sp
+  %empty = icmp eq i64 %sp, 0
+  br i1 %empty, label %trap, label %ok
+ok:
+  %next = sub i64 %sp, 1
+  store i64 %next, ptr @sp
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %next
+  %v = load i64, ptr %slot
+  ret i64 %v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %r = alloca [8 x i64]
+  store [8 x i64] zeroinitializer, ptr %r
+  %ret = call i64 @imp_main(ptr %r)
+  %status = trunc i64 %ret to i32
+  ret i32 %status
+}
+
+define internal i64 @imp_main(ptr %r) {
+entry:
+  br label %L0
+L0: ; JUMP_I 7
+  br label %L7
+L7: ; JUMP_I 17
+  br label %L17
+L17: ; MOVE_I 6 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 6, ptr %t1
+  br label %L18
+L18: ; PUSH_R 2
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t3 = load i64, ptr %t2
+  call void @push(i64 %t3)
+  br label %L19
+L19: ; MOVE_R 0 2
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t5, ptr %t6
+  br label %L20
+L20: ; MOVE_R 1 0
+  %t7 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t8 = load i64, ptr %t7
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t8, ptr %t9
+  br label %L21
+L21: ; MOVE_I 7 1
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 7, ptr %t10
+  br label %L22
+L22: ; CALL_I 8
+  call void @proc8(ptr %r)
+  br label %L23
+L23: ; MOVE_R 0 1
+  %t11 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t12 = load i64, ptr %t11
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t12, ptr %t13
+  br label %L24
+L24: ; MOVE_R 2 0
+  %t14 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t15 = load i64, ptr %t14
+  %t16 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t15, ptr %t16
+  br label %L25
+L25: ; POP_R 2
+  %t17 = call i64 @pop()
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t17, ptr %t18
+  br label %L26
+L26: ; ADD_R 1 0
+  %t19 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t20 = load i64, ptr %t19
+  %t21 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t22 = load i64, ptr %t21
+  %t23 = add i64 %t22, %t20
+  %t24 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t23, ptr %t24
+  br label %L27
+L27:
+  %t25 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t26 = load i64, ptr %t25
+  ret i64 %t26
+}
+
+define internal void @proc1(ptr %r) {
+entry:
+  br label %L1
+L1: ; BNE_I 0 1 3
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t2 = load i64, ptr %t1
+  %t3 = icmp ne i64 0, %t2
+  br i1 %t3, label %L3, label %L2
+L2: ; RET
+  ret void
+L3: ; ADD_R 0 2
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t7 = load i64, ptr %t6
+  %t8 = add i64 %t7, %t5
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t8, ptr %t9
+  br label %L4
+L4: ; SUB_I 1 1
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t11 = load i64, ptr %t10
+  %t12 = sub i64 %t11, 1
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t12, ptr %t13
+  br label %L5
+L5: ; JUMP_I 1
+  br label %L1
+L6: ; RET
+  ret void
+L7:
+  ret void
+}
+
+define internal void @proc8(ptr %r) {
+entry:
+  br label %L8
+L8: ; PUSH_R 3
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t2 = load i64, ptr %t1
+  call void @push(i64 %t2)
+  br label %L9
+L9: ; MOVE_R 1 3
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t4 = load i64, ptr %t3
+  %t5 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t4, ptr %t5
+  br label %L10
+L10: ; PUSH_R 1
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t7 = load i64, ptr %t6
+  call void @push(i64 %t7)
+  br label %L11
+L11: ; MOVE_R 3 1
+  %t8 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t9 = load i64, ptr %t8
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t9, ptr %t10
+  br label %L12
+L12: ; CALL_I 1
+  call void @proc1(ptr %r)
+  br label %L13
+L13: ; MOVE_R 1 3
+  %t11 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t12 = load i64, ptr %t11
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t12, ptr %t13
+  br label %L14
+L14: ; POP_R 1
+  %t14 = call i64 @pop()
+  %t15 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t14, ptr %t15
+  br label %L15
+L15: ; POP_R 3
+  %t16 = call i64 @pop()
+  %t17 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t16, ptr %t17
+  br label %L16
+L16: ; RET
+  ret void
+L17:
+  ret void
+}
@@ -0,0 +1,94 @@
+	.text
+	.globl _start
+_start:
+.L0:		// JUMP_I 7
+	b .L7
+.L1:		// BNE_I 0 0 3
+	cmp x19, #0
+	b.ne .L3
+.L2:		// RET
+	ret
+.L3:		// ADD_R 1 2
+	add x21, x21, x20
+.L4:		// SUB_I 1 0
+	sub x19, x19, #1
+.L5:		// JUMP_I 1
+	b .L1
+.L6:		// RET
+	ret
+.L7:		// JUMP_I 29
+	b .L29
+.L8:		// PUSH_R 3
+	str x22, [sp, #-16]!
+.L9:		// MOVE_I 0 1
+	movz x20, #0
+.L10:		// MOVE_R 0 3
+	mov x22, x19
+.L11:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L12:		// MOVE_R 1 2
+	mov x21, x20
+.L13:		// MOVE_R 0 1
+	mov x20, x19
+.L14:		// MOVE_R 3 0
+	mov x19, x22
+.L15:		// CALL_I 1
+	str x30, [sp, #-16]!
+	bl .L1
+	ldr x30, [sp], #16
+.L16:		// MOVE_R 0 3
+	mov x22, x19
+.L17:		// MOVE_R 1 0
+	mov x19, x20
+.L18:		// MOVE_R 2 1
+	mov x20, x21
+.L19:		// POP_R 2
+	ldr x21, [sp], #16
+.L20:		// MOVE_I 0 2
+	movz x21, #0
+.L21:		// MOVE_R 0 3
+	mov x22, x19
+.L22:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L23:		// MOVE_R 3 0
+	mov x19, x22
+.L24:		// CALL_I 1
+	str x30, [sp, #-16]!
+	bl .L1
+	ldr x30, [sp], #16
+.L25:		// MOVE_R 0 3
+	mov x22, x19
+.L26:		// POP_R 0
+	ldr x19, [sp], #16
+.L27:		// POP_R 3
+	ldr x22, [sp], #16
+.L28:		// RET
+	ret
+.L29:		// MOVE_I 2 1
+	movz x20, #2
+.L30:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L31:		// MOVE_R 1 0
+	mov x19, x20
+.L32:		// CALL_I 8
+	str x30, [sp, #-16]!
+	bl .L8
+	ldr x30, [sp], #16
+.L33:		// MOVE_R 2 3
+	mov x22, x21
+.L34:		// MOVE_R 1 2
+	mov x21, x20
+.L35:		// MOVE_R 0 1
+	mov x20, x19
+.L36:		// POP_R 0
+	ldr x19, [sp], #16
+.L37:		// ADD_R 1 0
+	add x19, x19, x20
+.L38:		// ADD_R 2 0
+	add x19, x19, x21
+.L39:		// ADD_R 3 0
+	add x19, x19, x22
+.L40:
+	mov x0, x19
+	mov x8, #93
+	svc #0
@@ -0,0 +1,277 @@
+; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [65536 x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %v) {
+entry:
+  %sp = load i64, ptr @sp
+  %full = icmp uge i64 %sp, 65536
+  br i1 %full, label %trap, label %ok
+ok:
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %sp
+  store i64 %v, ptr %slot
+  %next = add i64 %sp, 1
+  store i64 %next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %sp = load i64, ptr @sp
+  %empty = icmp eq i64 %sp, 0
+  br i1 %empty, label %trap, label %ok
+ok:
+  %next = sub i64 %sp, 1
+  store i64 %next, ptr @sp
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %next
+  %v = load i64, ptr %slot
+  ret i64 %v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %r = alloca [8 x i64]
+  store [8 x i64] zeroinitializer, ptr %r
+  %ret = call i64 @imp_main(ptr %r)
+  %status = trunc i64 %ret to i32
+  ret i32 %status
+}
+
+define internal i64 @imp_main(ptr %r) {
+entry:
+  br label %L0
+L0: ; JUMP_I 7
+  br label %L7
+L7: ; JUMP_I 29
+  br label %L29
+L29: ; MOVE_I 2 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 2, ptr %t1
+  br label %L30
+L30: ; PUSH_R 0
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t3 = load i64, ptr %t2
+  call void @push(i64 %t3)
+  br label %L31
+L31: ; MOVE_R 1 0
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t5, ptr %t6
+  br label %L32
+L32: ; CALL_I 8
+  call void @proc8(ptr %r)
+  br label %L33
+L33: ; MOVE_R 2 3
+  %t7 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t8 = load i64, ptr %t7
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t8, ptr %t9
+  br label %L34
+L34: ; MOVE_R 1 2
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t11 = load i64, ptr %t10
+  %t12 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t11, ptr %t12
+  br label %L35
+L35: ; MOVE_R 0 1
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t14 = load i64, ptr %t13
+  %t15 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t14, ptr %t15
+  br label %L36
+L36: ; POP_R 0
+  %t16 = call i64 @pop()
+  %t17 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t16, ptr %t17
+  br label %L37
+L37: ; ADD_R 1 0
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t19 = load i64, ptr %t18
+  %t20 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t21 = load i64, ptr %t20
+  %t22 = add i64 %t21, %t19
+  %t23 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t22, ptr %t23
+  br label %L38
+L38: ; ADD_R 2 0
+  %t24 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t25 = load i64, ptr %t24
+  %t26 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t27 = load i64, ptr %t26
+  %t28 = add i64 %t27, %t25
+  %t29 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t28, ptr %t29
+  br label %L39
+L39: ; ADD_R 3 0
+  %t30 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t31 = load i64, ptr %t30
+  %t32 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t33 = load i64, ptr %t32
+  %t34 = add i64 %t33, %t31
+  %t35 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t34, ptr %t35
+  br label %L40
+L40:
+  %t36 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t37 = load i64, ptr %t36
+  ret i64 %t37
+}
+
+define internal void @proc1(ptr %r) {
+entry:
+  br label %L1
+L1: ; BNE_I 0 0 3
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t2 = load i64, ptr %t1
+  %t3 = icmp ne i64 0, %t2
+  br i1 %t3, label %L3, label %L2
+L2: ; RET
+  ret void
+L3: ; ADD_R 1 2
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t7 = load i64, ptr %t6
+  %t8 = add i64 %t7, %t5
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t8, ptr %t9
+  br label %L4
+L4: ; SUB_I 1 0
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t11 = load i64, ptr %t10
+  %t12 = sub i64 %t11, 1
+  %t13 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t12, ptr %t13
+  br label %L5
+L5: ; JUMP_I 1
+  br label %L1
+L6: ; RET
+  ret void
+L7:
+  ret void
+}
+
+define internal void @proc8(ptr %r) {
+entry:
+  br label %L8
+L8: ; PUSH_R 3
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t2 = load i64, ptr %t1
+  call void @push(i64 %t2)
+  br label %L9
+L9: ; MOVE_I 0 1
+  %t3 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 0, ptr %t3
+  br label %L10
+L10: ; MOVE_R 0 3
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t5, ptr %t6
+  br label %L11
+L11: ; PUSH_R 2
+  %t7 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t8 = load i64, ptr %t7
+  call void @push(i64 %t8)
+  br label %L12
+L12: ; MOVE_R 1 2
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t10 = load i64, ptr %t9
+  %t11 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t10, ptr %t11
+  br label %L13
+L13: ; MOVE_R 0 1
+  %t12 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t13 = load i64, ptr %t12
+  %t14 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t13, ptr %t14
+  br label %L14
+L14: ; MOVE_R 3 0
+  %t15 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t16 = load i64, ptr %t15
+  %t17 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t16, ptr %t17
+  br label %L15
+L15: ; CALL_I 1
+  call void @proc1(ptr %r)
+  br label %L16
+L16: ; MOVE_R 0 3
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t19 = load i64, ptr %t18
+  %t20 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t19, ptr %t20
+  br label %L17
+L17: ; MOVE_R 1 0
+  %t21 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t22 = load i64, ptr %t21
+  %t23 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t22, ptr %t23
+  br label %L18
+L18: ; MOVE_R 2 1
+  %t24 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t25 = load i64, ptr %t24
+  %t26 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t25, ptr %t26
+  br label %L19
+L19: ; POP_R 2
+  %t27 = call i64 @pop()
+  %t28 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t27, ptr %t28
+  br label %L20
+L20: ; MOVE_I 0 2
+  %t29 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 0, ptr %t29
+  br label %L21
+L21: ; MOVE_R 0 3
+  %t30 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t31 = load i64, ptr %t30
+  %t32 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t31, ptr %t32
+  br label %L22
+L22: ; PUSH_R 0
+  %t33 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t34 = load i64, ptr %t33
+  call void @push(i64 %t34)
+  br label %L23
+L23: ; MOVE_R 3 0
+  %t35 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t36 = load i64, ptr %t35
+  %t37 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t36, ptr %t37
+  br label %L24
+L24: ; CALL_I 1
+  call void @proc1(ptr %r)
+  br label %L25
+L25: ; MOVE_R 0 3
+  %t38 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t39 = load i64, ptr %t38
+  %t40 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t39, ptr %t40
+  br label %L26
+L26: ; POP_R 0
+  %t41 = call i64 @pop()
+  %t42 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t41, ptr %t42
+  br label %L27
+L27: ; POP_R 3
+  %t43 = call i64 @pop()
+  %t44 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t43, ptr %t44
+  br label %L28
+L28: ; RET
+  ret void
+L29:
+  ret void
+}
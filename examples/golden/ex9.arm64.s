@@ -0,0 +1,75 @@
+	.text
+	.globl _start
+_start:
+.L0:		// MOVE_I 7 1
+	movz x20, #7
+.L1:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L2:		// MOVE_R 1 0
+	mov x19, x20
+.L3:		// CALL_I 18
+	str x30, [sp, #-16]!
+	bl .L18
+	ldr x30, [sp], #16
+.L4:		// MOVE_R 1 2
+	mov x21, x20
+.L5:		// MOVE_R 0 1
+	mov x20, x19
+.L6:		// POP_R 0
+	ldr x19, [sp], #16
+.L7:		// MOVE_I 10 1
+	movz x20, #10
+.L8:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L9:		// MOVE_R 1 0
+	mov x19, x20
+.L10:		// CALL_I 18
+	str x30, [sp, #-16]!
+	bl .L18
+	ldr x30, [sp], #16
+.L11:		// MOVE_R 1 3
+	mov x22, x20
+.L12:		// MOVE_R 0 1
+	mov x20, x19
+.L13:		// POP_R 0
+	ldr x19, [sp], #16
+.L14:		// ADD_R 2 0
+	add x19, x19, x21
+.L15:		// ADD_R 3 0
+	add x19, x19, x22
+.L16:		// ADD_R 3 0
+	add x19, x19, x22
+.L17:		// JUMP_I 24
+	b .L24
+.L18:		// MOVE_I 1 1
+	movz x20, #1
+.L19:		// BNE_I 0 0 21
+	cmp x19, #0
+	b.ne .L21
+.L20:		// RET
+	ret
+.L21:		// SUB_I 1 0
+	sub x19, x19, #1
+.L22:		// TAIL_I 25
+	b .L25
+.L23:		// RET
+	ret
+.L24:		// JUMP_I 31
+	b .L31
+.L25:		// MOVE_I 0 1
+	movz x20, #0
+.L26:		// BNE_I 0 0 28
+	cmp x19, #0
+	b.ne .L28
+.L27:		// RET
+	ret
+.L28:		// SUB_I 1 0
+	sub x19, x19, #1
+.L29:		// TAIL_I 18
+	b .L18
+.L30:		// RET
+	ret
+.L31:
+	mov x0, x19
+	mov x8, #93
+	svc #0
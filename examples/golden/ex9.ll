@@ -0,0 +1,219 @@
+; Generated by imp.
+
+; Psuedo stack for PUSH_R and POP_R. Return addresses live on the native stack.
+@stack = internal global [65536 x i64] zeroinitializer
+@sp = internal global i64 0
+
+declare void @llvm.trap()
+
+define internal void @push(i64 %v) {
+entry:
+  %sp = load i64, ptr @sp
+  %full = icmp uge i64 %sp, 65536
+  br i1 %full, label %trap, label %ok
+ok:
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %sp
+  store i64 %v, ptr %slot
+  %next = add i64 %sp, 1
+  store i64 %next, ptr @sp
+  ret void
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+define internal i64 @pop() {
+entry:
+  %sp = load i64, ptr @sp
+  %empty = icmp eq i64 %sp, 0
+  br i1 %empty, label %trap, label %ok
+ok:
+  %next = sub i64 %sp, 1
+  store i64 %next, ptr @sp
+  %slot = getelementptr inbounds [65536 x i64], ptr @stack, i64 0, i64 %next
+  %v = load i64, ptr %slot
+  ret i64 %v
+trap:
+  call void @llvm.trap()
+  unreachable
+}
+
+; Runs the program on a zeroed register file and exits with register 0.
+define i32 @main() {
+entry:
+  %r = alloca [8 x i64]
+  store [8 x i64] zeroinitializer, ptr %r
+  %ret = call i64 @imp_main(ptr %r)
+  %status = trunc i64 %ret to i32
+  ret i32 %status
+}
+
+define internal i64 @imp_main(ptr %r) {
+entry:
+  br label %L0
+L0: ; MOVE_I 7 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 7, ptr %t1
+  br label %L1
+L1: ; PUSH_R 0
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t3 = load i64, ptr %t2
+  call void @push(i64 %t3)
+  br label %L2
+L2: ; MOVE_R 1 0
+  %t4 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t5 = load i64, ptr %t4
+  %t6 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t5, ptr %t6
+  br label %L3
+L3: ; CALL_I 18
+  call void @proc18(ptr %r)
+  br label %L4
+L4: ; MOVE_R 1 2
+  %t7 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t8 = load i64, ptr %t7
+  %t9 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  store i64 %t8, ptr %t9
+  br label %L5
+L5: ; MOVE_R 0 1
+  %t10 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t11 = load i64, ptr %t10
+  %t12 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t11, ptr %t12
+  br label %L6
+L6: ; POP_R 0
+  %t13 = call i64 @pop()
+  %t14 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t13, ptr %t14
+  br label %L7
+L7: ; MOVE_I 10 1
+  %t15 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 10, ptr %t15
+  br label %L8
+L8: ; PUSH_R 0
+  %t16 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t17 = load i64, ptr %t16
+  call void @push(i64 %t17)
+  br label %L9
+L9: ; MOVE_R 1 0
+  %t18 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t19 = load i64, ptr %t18
+  %t20 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t19, ptr %t20
+  br label %L10
+L10: ; CALL_I 18
+  call void @proc18(ptr %r)
+  br label %L11
+L11: ; MOVE_R 1 3
+  %t21 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  %t22 = load i64, ptr %t21
+  %t23 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  store i64 %t22, ptr %t23
+  br label %L12
+L12: ; MOVE_R 0 1
+  %t24 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t25 = load i64, ptr %t24
+  %t26 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 %t25, ptr %t26
+  br label %L13
+L13: ; POP_R 0
+  %t27 = call i64 @pop()
+  %t28 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t27, ptr %t28
+  br label %L14
+L14: ; ADD_R 2 0
+  %t29 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 2
+  %t30 = load i64, ptr %t29
+  %t31 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t32 = load i64, ptr %t31
+  %t33 = add i64 %t32, %t30
+  %t34 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t33, ptr %t34
+  br label %L15
+L15: ; ADD_R 3 0
+  %t35 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t36 = load i64, ptr %t35
+  %t37 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t38 = load i64, ptr %t37
+  %t39 = add i64 %t38, %t36
+  %t40 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t39, ptr %t40
+  br label %L16
+L16: ; ADD_R 3 0
+  %t41 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 3
+  %t42 = load i64, ptr %t41
+  %t43 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t44 = load i64, ptr %t43
+  %t45 = add i64 %t44, %t42
+  %t46 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t45, ptr %t46
+  br label %L17
+L17: ; JUMP_I 24
+  br label %L24
+L24: ; JUMP_I 31
+  br label %L31
+L31:
+  %t47 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t48 = load i64, ptr %t47
+  ret i64 %t48
+}
+
+define internal void @proc18(ptr %r) {
+entry:
+  br label %L18
+L18: ; MOVE_I 1 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 1, ptr %t1
+  br label %L19
+L19: ; BNE_I 0 0 21
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t3 = load i64, ptr %t2
+  %t4 = icmp ne i64 0, %t3
+  br i1 %t4, label %L21, label %L20
+L20: ; RET
+  ret void
+L21: ; SUB_I 1 0
+  %t5 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t6 = load i64, ptr %t5
+  %t7 = sub i64 %t6, 1
+  %t8 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t7, ptr %t8
+  br label %L22
+L22: ; TAIL_I 25
+  musttail call void @proc25(ptr %r)
+  ret void
+L23: ; RET
+  ret void
+L24:
+  ret void
+}
+
+define internal void @proc25(ptr %r) {
+entry:
+  br label %L25
+L25: ; MOVE_I 0 1
+  %t1 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 1
+  store i64 0, ptr %t1
+  br label %L26
+L26: ; BNE_I 0 0 28
+  %t2 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t3 = load i64, ptr %t2
+  %t4 = icmp ne i64 0, %t3
+  br i1 %t4, label %L28, label %L27
+L27: ; RET
+  ret void
+L28: ; SUB_I 1 0
+  %t5 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  %t6 = load i64, ptr %t5
+  %t7 = sub i64 %t6, 1
+  %t8 = getelementptr inbounds [8 x i64], ptr %r, i64 0, i64 0
+  store i64 %t7, ptr %t8
+  br label %L29
+L29: ; TAIL_I 18
+  musttail call void @proc18(ptr %r)
+  ret void
+L30: ; RET
+  ret void
+L31:
+  ret void
+}
@@ -0,0 +1,114 @@
+	.text
+	.globl _start
+_start:
+.L0:		// JUMP_I 7
+	b .L7
+.L1:		// BNE_I 0 0 3
+	cmp x19, #0
+	b.ne .L3
+.L2:		// RET
+	ret
+.L3:		// ADD_R 1 2
+	add x21, x21, x20
+.L4:		// SUB_I 1 0
+	sub x19, x19, #1
+.L5:		// JUMP_I 1
+	b .L1
+.L6:		// RET
+	ret
+.L7:		// JUMP_I 11
+	b .L11
+.L8:		// MOVE_I 0 2
+	movz x21, #0
+.L9:		// TAIL_I 1
+	b .L1
+.L10:		// RET
+	ret
+.L11:		// JUMP_I 29
+	b .L29
+.L12:		// BNE_I 0 0 14
+	cmp x19, #0
+	b.ne .L14
+.L13:		// RET
+	ret
+.L14:		// BNE_I 1 0 16
+	cmp x19, #1
+	b.ne .L16
+.L15:		// RET
+	ret
+.L16:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L17:		// MOVE_R 1 2
+	mov x21, x20
+.L18:		// MOVE_R 0 1
+	mov x20, x19
+.L19:		// POP_R 0
+	ldr x19, [sp], #16
+.L20:		// CALL_I 8
+	str x30, [sp, #-16]!
+	bl .L8
+	ldr x30, [sp], #16
+.L21:		// PUSH_R 0
+	str x19, [sp, #-16]!
+.L22:		// MOVE_R 1 0
+	mov x19, x20
+.L23:		// MOVE_R 2 1
+	mov x20, x21
+.L24:		// POP_R 2
+	ldr x21, [sp], #16
+.L25:		// MOVE_R 1 2
+	mov x21, x20
+.L26:		// SUB_I 1 0
+	sub x19, x19, #1
+.L27:		// JUMP_I 12
+	b .L12
+.L28:		// RET
+	ret
+.L29:		// JUMP_I 41
+	b .L41
+.L30:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L31:		// MOVE_I 1 1
+	movz x20, #1
+.L32:		// PUSH_R 2
+	str x21, [sp, #-16]!
+.L33:		// MOVE_R 1 2
+	mov x21, x20
+.L34:		// POP_R 1
+	ldr x20, [sp], #16
+.L35:		// CALL_I 12
+	str x30, [sp, #-16]!
+	bl .L12
+	ldr x30, [sp], #16
+.L36:		// PUSH_R 1
+	str x20, [sp, #-16]!
+.L37:		// MOVE_R 2 1
+	mov x20, x21
+.L38:		// POP_R 2
+	ldr x21, [sp], #16
+.L39:		// POP_R 2
+	ldr x21, [sp], #16
+.L40:		// RET
+	ret
+.L41:		// MOVE_I 5 2
+	movz x21, #5
+.L42:		// PUSH_R 1
+	str x20, [sp, #-16]!
+.L43:		// MOVE_R 0 1
+	mov x20, x19
+.L44:		// MOVE_R 2 0
+	mov x19, x21
+.L45:		// CALL_I 30
+	str x30, [sp, #-16]!
+	bl .L30
+	ldr x30, [sp], #16
+.L46:		// MOVE_R 0 2
+	mov x21, x19
+.L47:		// MOVE_R 1 0
+	mov x19, x20
+.L48:		// POP_R 1
+	ldr x20, [sp], #16
+.L49:
+	mov x0, x19
+	mov x8, #93
+	svc #0
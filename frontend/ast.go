@@ -19,23 +19,59 @@ type (
 		name string
 		line int
 	}
+
+	// A number written out, e.g. #-1, #0x1f or #'A'.
+	NumLit struct {
+		text  string
+		value int64
+		line  int
+	}
 )
 
 func (r RegAlias) Alias() {}
 func (n NumAlias) Alias() {}
 func (c CmdAlias) Alias() {}
+func (n NumLit) Alias()   {}
 
 func (r RegAlias) String() string { return r.name }
 func (n NumAlias) String() string { return n.name }
 func (c CmdAlias) String() string { return c.name }
+func (n NumLit) String() string   { return n.text }
 
 func (r RegAlias) Type() string { return "RegAlias" }
 func (n NumAlias) Type() string { return "NumAlias" }
 func (c CmdAlias) Type() string { return "CmdAlias" }
+func (n NumLit) Type() string   { return "NumLit" }
 
 func (r RegAlias) Pos() int { return r.line }
 func (n NumAlias) Pos() int { return n.line }
 func (c CmdAlias) Pos() int { return c.line }
+func (n NumLit) Pos() int   { return n.line }
+
+func (n NumLit) Value() int64 { return n.value }
+
+// How a procedure uses a param: in params are only read, out params are only
+// written and inout params are both.
+type Mode int
+
+const (
+	NoMode Mode = iota
+	In
+	Out
+	InOut
+)
+
+func (m Mode) String() string {
+	switch m {
+	case In:
+		return "in"
+	case Out:
+		return "out"
+	case InOut:
+		return "inout"
+	}
+	return ""
+}
 
 type (
 	Stmt interface {
@@ -52,17 +88,61 @@ type (
 		Cmd    CmdAlias
 		Params []Alias
 		Body   []Stmt
+
+		// Mode of each param, or NoMode if it doesn't have one.
+		Modes []Mode
+
+		// File the decl was used from, or empty if it's from the file being
+		// compiled.
+		file string
+	}
+	Use struct {
+		Path string
+		line int
+	}
+	Const struct {
+		Name  NumAlias
+		Value Alias
+
+		// As for Decl.
+		file string
+	}
+	RegDecl struct {
+		Name  RegAlias
+		Value Alias
+	}
+	Local struct {
+		Name RegAlias
 	}
 )
 
-func (c Call) Stmt() {}
-func (d Decl) Stmt() {}
+func (c Call) Stmt()    {}
+func (d Decl) Stmt()    {}
+func (u Use) Stmt()     {}
+func (c Const) Stmt()   {}
+func (r RegDecl) Stmt() {}
+func (l Local) Stmt()   {}
+
+func (c Call) String() string    { return c.Cmd.String() }
+func (d Decl) String() string    { return d.Cmd.String() }
+func (u Use) String() string     { return u.Path }
+func (c Const) String() string   { return c.Name.String() }
+func (r RegDecl) String() string { return r.Name.String() }
+func (l Local) String() string   { return l.Name.String() }
 
-func (c Call) String() string { return c.Cmd.String() }
-func (d Decl) String() string { return d.Cmd.String() }
+func (c Call) Type() string    { return "Call" }
+func (d Decl) Type() string    { return "Decl" }
+func (u Use) Type() string     { return "Use" }
+func (c Const) Type() string   { return "Const" }
+func (r RegDecl) Type() string { return "RegDecl" }
+func (l Local) Type() string   { return "Local" }
 
-func (c Call) Type() string { return "Call" }
-func (d Decl) Type() string { return "Decl" }
+func (c Call) Pos() int    { return c.Cmd.Pos() }
+func (d Decl) Pos() int    { return d.Cmd.Pos() }
+func (u Use) Pos() int     { return u.line }
+func (c Const) Pos() int   { return c.Name.Pos() }
+func (r RegDecl) Pos() int { return r.Name.Pos() }
+func (l Local) Pos() int   { return l.Name.Pos() }
 
-func (c Call) Pos() int { return c.Cmd.Pos() }
-func (d Decl) Pos() int { return d.Cmd.Pos() }
+func (d Decl) File() string  { return d.file }
+func (c Const) File() string { return c.file }
@@ -36,6 +36,17 @@ func DumpArgs(args []Alias) string {
 	return b.String()
 }
 
+func DumpModes(modes []Mode) string {
+	names := make([]string, len(modes))
+	for i, mode := range modes {
+		names[i] = mode.String()
+		if mode == NoMode {
+			names[i] = "none"
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
 func DumpStmt(stmt Stmt) string {
 	switch stmt := stmt.(type) {
 	case Call:
@@ -55,13 +66,55 @@ func DumpStmt(stmt Stmt) string {
 			"type: %s\n" +
 			"line: %d\n" +
 			"params: [%s]\n" +
+			"modes: [%s]\n" +
 			"body: [\n%s]\n",
 			stmt,
 			stmt.Type(),
 			stmt.Pos(),
 			errors.Indent(DumpArgs(stmt.Params)),
+			DumpModes(stmt.Modes),
 			errors.Indent(DumpAst(stmt.Body)),
 		)
+	case Const:
+		return fmt.Sprintf(
+			"name: \"%s\"\n" +
+			"type: %s\n" +
+			"line: %d\n" +
+			"value: [%s]\n",
+			stmt,
+			stmt.Type(),
+			stmt.Pos(),
+			errors.Indent(DumpArgs([]Alias{stmt.Value})),
+		)
+	case RegDecl:
+		return fmt.Sprintf(
+			"name: \"%s\"\n" +
+			"type: %s\n" +
+			"line: %d\n" +
+			"value: [%s]\n",
+			stmt,
+			stmt.Type(),
+			stmt.Pos(),
+			errors.Indent(DumpArgs([]Alias{stmt.Value})),
+		)
+	case Local:
+		return fmt.Sprintf(
+			"name: \"%s\"\n" +
+			"type: %s\n" +
+			"line: %d\n",
+			stmt,
+			stmt.Type(),
+			stmt.Pos(),
+		)
+	case Use:
+		return fmt.Sprintf(
+			"path: \"%s\"\n" +
+			"type: %s\n" +
+			"line: %d\n",
+			stmt,
+			stmt.Type(),
+			stmt.Pos(),
+		)
 	}
 	return ""
 }
@@ -1,6 +1,8 @@
 package frontend
 
 import (
+	"unicode/utf8"
+
 	"github.com/ialeinbach/imp/errors"
 )
 
@@ -9,10 +11,23 @@ const (
 	maxRegLength int = 32
 	maxNumLength int = 32
 	maxCmtLength int = 79
+	maxStrLength int = 255
+	maxLitLength int = 128
 
 	numPrefix rune = '#'
 	regPrefix rune = '@'
 	cmtPrefix rune = '/'
+	strQuote  rune = '"'
+	charQuote rune = '\''
+
+	// Reserved cmd names.
+	useKeyword   string = "use"
+	constKeyword string = "const"
+	regKeyword   string = "reg"
+	localKeyword string = "local"
+	inKeyword    string = "in"
+	outKeyword   string = "out"
+	inoutKeyword string = "inout"
 )
 
 //
@@ -31,11 +46,17 @@ type lexer struct {
 	// Lexer state for debugging.
 	line int
 	err  error
+
+	// Set after '=', where num literals can go without their prefix.
+	bare bool
 }
 
 type token struct {
 	lexeme string
 	line   int
+
+	// Value of a number literal.
+	value int64
 }
 
 func Lexer(input string) *lexer {
@@ -100,6 +121,15 @@ var (
 		return predAlpha(rn) || predHex(rn)
 	}
 
+	// Num literals start with a digit, a minus sign or a character literal,
+	// and are checked by numLit once lexed.
+	predLitStart lexerPred = func(rn rune) bool {
+		return predDec(rn) || rn == '-' || rn == charQuote
+	}
+	predLitBody lexerPred = func(rn rune) bool {
+		return predAlpha(rn) || predDec(rn) || rn == '_'
+	}
+
 	// Comments are anything for a single line.
 	predCmtPrefix lexerPred = func(rn rune) bool {
 		return rn == cmtPrefix
@@ -107,6 +137,14 @@ var (
 	predCmtBody lexerPred = func(rn rune) bool {
 		return rn != '\n'
 	}
+
+	// Strings are anything between double quotes on a single line.
+	predStrPrefix lexerPred = func(rn rune) bool {
+		return rn == strQuote
+	}
+	predStrBody lexerPred = func(rn rune) bool {
+		return rn != strQuote && rn != '\n'
+	}
 )
 
 //
@@ -199,6 +237,53 @@ func (l *lexer) lexNum() int {
 	return l.lexPrefixed(predNumPrefix, predNumBody, maxNumLength)
 }
 
+// Lexes a num literal. The num prefix isn't part of the lexeme, and is
+// optional if bare is true.
+func (l *lexer) lexLit(bare bool) int {
+	errors.DebugLexer(2, true, "Lexing LIT\n")
+	rest := l.input[l.curr:]
+	switch {
+	case len(rest) >= 2 && rune(rest[0]) == numPrefix && predLitStart(rune(rest[1])):
+		l.curr++
+		l.start++
+	case !bare || !predLitStart(rune(rest[0])):
+		return 0
+	}
+
+	if l.head() == charQuote {
+		return l.lexChar()
+	}
+	var n int
+	if l.head() == '-' {
+		l.curr++
+		n++
+	}
+	return n + l.lexPred(predLitBody, maxLitLength)
+}
+
+// Lexes a character literal, quotes included. The character can be an escape
+// sequence, which is checked by numLit.
+func (l *lexer) lexChar() int {
+	end := l.curr + 1
+	if end < len(l.input) && l.input[end] == '\\' {
+		end += 2
+	} else {
+		rn, size := utf8.DecodeRuneInString(l.input[end:])
+		if rn != '\n' {
+			end += size
+		}
+	}
+	if end >= len(l.input) || rune(l.input[end]) != charQuote {
+		l.Error("unterminated character literal")
+		return 0
+	}
+	end++
+
+	n := end - l.curr
+	l.curr = end
+	return n
+}
+
 func (l *lexer) lexCmt() int {
 	errors.DebugLexer(2, true, "Lexing CMT\n")
 	return l.lexPrefixed(predCmtPrefix, predCmtBody, maxCmtLength)
@@ -206,6 +291,18 @@ func (l *lexer) lexCmt() int {
 
 }
 
+// Lexes a string up to its closing quote, which is left for the caller to
+// skip after emitting the string.
+func (l *lexer) lexStr() int {
+	errors.DebugLexer(2, true, "Lexing STR\n")
+	n := l.lexPrefixed(predStrPrefix, predStrBody, maxStrLength)
+	if n > 0 && (len(l.input[l.curr:]) == 0 || l.head() != strQuote) {
+		l.Error("unterminated string")
+		return 0
+	}
+	return n
+}
+
 //
 // Handles For Goyacc
 //
@@ -235,9 +332,10 @@ func (l *lexer) Lex(lval *yySymType) int {
 
 		// Lex trivial tokens. Used as-is in parser i.e. no corresponding item
 		// on goyacc value stack.
-		case ':', ',', '{', '}':
+		case ':', ',', '{', '}', '=':
 			l.curr++
 			l.start++
+			l.bare = ch == '='
 
 			errors.DebugLexer(1, true, "Emitting SYM(%c)\n", ch)
 			return ch
@@ -248,8 +346,42 @@ func (l *lexer) Lex(lval *yySymType) int {
 
 		// Lex non-trivial tokens (i.e. can be directly mapped to nodes on the
 		// AST). Pass control of lexer to its methods.
+		bare := l.bare
+		l.bare = false
 		switch {
+		case l.lexLit(bare) > 0:
+			value, err := numLit(l.lexeme())
+			if err != nil {
+				l.Error(err.Error())
+				return ch
+			}
+			l.emit("LIT", lval)
+			lval.tok.value = value
+			return LIT
 		case l.lexCmd() > 0:
+			switch l.lexeme() {
+			case useKeyword:
+				l.emit("USE", lval)
+				return USE
+			case constKeyword:
+				l.emit("CONST", lval)
+				return CONST
+			case regKeyword:
+				l.emit("REGKW", lval)
+				return REGKW
+			case localKeyword:
+				l.emit("LOCAL", lval)
+				return LOCAL
+			case inKeyword:
+				l.emit("IN", lval)
+				return IN
+			case outKeyword:
+				l.emit("OUT", lval)
+				return OUT
+			case inoutKeyword:
+				l.emit("INOUT", lval)
+				return INOUT
+			}
 			l.emit("CMD", lval)
 			return CMD
 		case l.lexReg() > 0:
@@ -261,6 +393,11 @@ func (l *lexer) Lex(lval *yySymType) int {
 		case l.lexCmt() > 0:
 			l.emit("CMT", lval)
 			return CMT
+		case l.lexStr() > 0:
+			l.emit("STR", lval)
+			l.curr++ // closing quote
+			l.start++
+			return STR
 		}
 
 		l.Error(errors.UnrecognizedInput(rune(ch)).Error())
@@ -270,7 +407,10 @@ func (l *lexer) Lex(lval *yySymType) int {
 	return ch
 }
 
-// Satisfies yyLexer.
+// Satisfies yyLexer. Keeps the first error, since the lexer's own errors are
+// more telling than the syntax error the parser reports after them.
 func (l *lexer) Error(str string) {
-	l.err = errors.New(str)
+	if l.err == nil {
+		l.err = errors.New(str)
+	}
 }
@@ -0,0 +1,69 @@
+package frontend
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Escape sequences allowed in character literals.
+var charEscapes = map[byte]int64{
+	'0':  0,
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'\\': '\\',
+	'\'': '\'',
+}
+
+// Returns the value of a number literal, without its prefix. Literals are
+// either integers or characters:
+//
+//	-12        decimal, without leading zeros
+//	0x1f       hexadecimal, also 0b for binary and 0o for octal
+//	1_000_000  underscores can separate digits
+//	'A'        a character or an escape sequence like '\n'
+func numLit(lit string) (int64, error) {
+	if strings.HasPrefix(lit, "'") {
+		return charLit(lit)
+	}
+
+	digits := strings.TrimPrefix(lit, "-")
+	if len(digits) > 1 && digits[0] == '0' && !strings.ContainsRune("xXbBoO", rune(digits[1])) {
+		return 0, errors.New("bad number literal: #%s (use 0o for octal)", lit)
+	}
+
+	n, err := strconv.ParseInt(lit, 0, 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return 0, errors.NumLitOutOfRange(lit)
+		}
+		return 0, errors.BadNumLit(lit)
+	}
+	return n, nil
+}
+
+// Returns the value of a character literal, including its quotes.
+func charLit(lit string) (int64, error) {
+	if len(lit) < 3 || !strings.HasSuffix(lit, "'") {
+		return 0, errors.BadNumLit(lit)
+	}
+	body := lit[1 : len(lit)-1]
+
+	if body[0] == '\\' {
+		if len(body) == 2 {
+			if n, ok := charEscapes[body[1]]; ok {
+				return n, nil
+			}
+		}
+		return 0, errors.BadNumLit(lit)
+	}
+
+	rn, size := utf8.DecodeRuneInString(body)
+	if rn == utf8.RuneError || size != len(body) {
+		return 0, errors.BadNumLit(lit)
+	}
+	return int64(rn), nil
+}
@@ -21,18 +21,35 @@ func Parse(input string) ([]Stmt, error) {
 	return abstractSyntaxTree, nil
 }
 
+// Params of a decl along with their modes.
+type paramList struct {
+	aliases []Alias
+	modes   []Mode
+}
+
 type yySymType struct {
 	yys      int
 	tok      token
 	arglist  []Alias
 	stmtlist []Stmt
+	params   paramList
+	mode     Mode
 }
 
 const CMD = 57346
 const REG = 57347
 const NUM = 57348
-const CMT = 57349
-const CR = 57350
+const LIT = 57349
+const CMT = 57350
+const STR = 57351
+const USE = 57352
+const CONST = 57353
+const REGKW = 57354
+const LOCAL = 57355
+const IN = 57356
+const OUT = 57357
+const INOUT = 57358
+const CR = 57359
 
 var yyToknames = [...]string{
 	"$end",
@@ -41,20 +58,31 @@ var yyToknames = [...]string{
 	"CMD",
 	"REG",
 	"NUM",
+	"LIT",
 	"CMT",
+	"STR",
+	"USE",
+	"CONST",
+	"REGKW",
+	"LOCAL",
+	"IN",
+	"OUT",
+	"INOUT",
 	"CR",
 	"':'",
 	"'{'",
 	"'}'",
+	"'='",
 	"','",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-var yyExca = [...]int{
+var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
@@ -62,68 +90,87 @@ var yyExca = [...]int{
 
 const yyPrivate = 57344
 
-const yyLast = 31
-
-var yyAct = [...]int{
-
-	3, 11, 2, 10, 22, 23, 16, 13, 14, 12,
-	8, 15, 6, 9, 20, 7, 8, 27, 1, 9,
-	5, 7, 21, 18, 19, 25, 4, 10, 26, 24,
-	17,
+const yyLast = 72
+
+var yyAct = [...]int8{
+	3, 19, 28, 18, 2, 29, 38, 21, 22, 23,
+	24, 25, 26, 49, 45, 47, 46, 48, 20, 33,
+	12, 34, 37, 36, 17, 35, 13, 14, 15, 16,
+	27, 12, 10, 40, 11, 17, 57, 13, 14, 15,
+	16, 30, 31, 32, 1, 11, 9, 50, 51, 8,
+	54, 7, 52, 53, 6, 40, 55, 18, 5, 56,
+	30, 31, 32, 4, 41, 39, 0, 0, 0, 42,
+	43, 44,
 }
-var yyPact = [...]int{
 
-	12, -1000, 12, -1000, 1, 1, 1, 7, 18, -1000,
-	-1000, -1000, 1, -1000, -1000, 18, -1000, -8, -1000, -1000,
-	-1000, -5, 18, 1, -1000, 12, 6, -1000,
+var yyPact = [...]int16{
+	27, -1000, 27, -1000, 1, 1, 1, 1, 1, 1,
+	1, 26, 36, 10, 15, 20, 18, -1000, -1000, -1000,
+	1, -1000, -1000, -1000, -1000, -1000, -1000, 55, -1000, -8,
+	-1000, -1000, -1000, -1000, -5, -6, -1000, -1000, -2, -9,
+	-1000, 36, -1000, -1000, -1000, 36, 36, 36, 1, 55,
+	-1000, -1000, -1000, -1000, 27, -1000, 16, -1000,
 }
-var yyPgo = [...]int{
 
-	0, 30, 6, 26, 20, 0, 2, 18, 1, 12,
+var yyPgo = [...]int8{
+	0, 5, 2, 65, 6, 64, 63, 58, 54, 51,
+	49, 46, 0, 4, 44, 1, 32,
 }
-var yyR1 = [...]int{
 
-	0, 7, 6, 6, 5, 5, 5, 3, 4, 9,
-	2, 2, 2, 1, 1, 8, 8,
+var yyR1 = [...]int8{
+	0, 14, 13, 13, 12, 12, 12, 12, 12, 12,
+	12, 6, 7, 8, 9, 10, 11, 16, 2, 2,
+	2, 4, 4, 4, 3, 3, 5, 5, 5, 1,
+	1, 1, 15, 15,
 }
-var yyR2 = [...]int{
 
-	0, 1, 2, 1, 2, 2, 2, 7, 2, 1,
-	0, 3, 1, 1, 1, 2, 1,
+var yyR2 = [...]int8{
+	0, 1, 2, 1, 2, 2, 2, 2, 2, 2,
+	2, 7, 2, 2, 4, 4, 2, 1, 0, 3,
+	1, 0, 3, 1, 1, 2, 1, 1, 1, 1,
+	1, 1, 2, 1,
 }
-var yyChk = [...]int{
 
-	-1000, -7, -6, -5, -3, -4, -9, 9, 4, 7,
-	-5, -8, 8, -8, -8, 4, -2, -1, 5, 6,
-	-8, -2, 12, 10, -2, -8, -6, 11,
+var yyChk = [...]int16{
+	-1000, -14, -13, -12, -6, -7, -8, -9, -10, -11,
+	-16, 18, 4, 10, 11, 12, 13, 8, -12, -15,
+	17, -15, -15, -15, -15, -15, -15, 4, -2, -1,
+	5, 6, 7, 9, 6, 5, 5, -15, -4, -3,
+	-1, -5, 14, 15, 16, 22, 21, 21, 19, 22,
+	-1, -2, -1, -1, -15, -4, -13, 20,
 }
-var yyDef = [...]int{
 
-	0, -2, 1, 3, 0, 0, 0, 0, 10, 9,
-	2, 4, 16, 5, 6, 10, 8, 12, 13, 14,
-	15, 0, 10, 0, 11, 0, 0, 7,
+var yyDef = [...]int8{
+	0, -2, 1, 3, 0, 0, 0, 0, 0, 0,
+	0, 0, 18, 0, 0, 0, 0, 17, 2, 4,
+	33, 5, 6, 7, 8, 9, 10, 21, 12, 20,
+	29, 30, 31, 13, 0, 0, 16, 32, 0, 23,
+	24, 0, 26, 27, 28, 18, 0, 0, 0, 21,
+	25, 19, 14, 15, 0, 22, 0, 11,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 12, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 9, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 22, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 18, 3,
+	3, 21, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 10, 3, 11,
+	3, 3, 3, 19, 3, 20,
 }
-var yyTok2 = [...]int{
 
-	2, 3, 4, 5, 6, 7, 8,
+var yyTok2 = [...]int8{
+	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
+	12, 13, 14, 15, 16, 17,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -203,9 +250,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -215,13 +262,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -252,30 +299,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -330,7 +377,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -341,8 +388,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -355,7 +402,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -364,18 +411,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -397,10 +444,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -436,7 +483,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -447,16 +494,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -501,17 +548,41 @@ yydefault:
 	case 6:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			errors.DebugParser(1, true, "stmt -> comment delim\n")
+			yyVAL.stmtlist = yyDollar[1].stmtlist
+			errors.DebugParser(1, true, "stmt -> use delim\n")
 		}
 	case 7:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmtlist = yyDollar[1].stmtlist
+			errors.DebugParser(1, true, "stmt -> const delim\n")
+		}
+	case 8:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmtlist = yyDollar[1].stmtlist
+			errors.DebugParser(1, true, "stmt -> regdecl delim\n")
+		}
+	case 9:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmtlist = yyDollar[1].stmtlist
+			errors.DebugParser(1, true, "stmt -> local delim\n")
+		}
+	case 10:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			errors.DebugParser(1, true, "stmt -> comment delim\n")
+		}
+	case 11:
 		yyDollar = yyS[yypt-7 : yypt+1]
 		{
 			cmd := CmdAlias{yyDollar[2].tok.lexeme, yyDollar[2].tok.line}
-			decl := Decl{cmd, yyDollar[3].arglist, yyDollar[6].stmtlist}
+			decl := Decl{Cmd: cmd, Params: yyDollar[3].params.aliases, Modes: yyDollar[3].params.modes, Body: yyDollar[6].stmtlist}
 			yyVAL.stmtlist = []Stmt{decl}
-			errors.DebugParser(1, true, "decl -> :CMD args { delim program }\n")
+			errors.DebugParser(1, true, "decl -> :CMD params { delim program }\n")
 		}
-	case 8:
+	case 12:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			cmd := CmdAlias{yyDollar[1].tok.lexeme, yyDollar[1].tok.line}
@@ -519,49 +590,138 @@ yydefault:
 			yyVAL.stmtlist = []Stmt{call}
 			errors.DebugParser(1, true, "call -> CMD args\n")
 		}
-	case 9:
+	case 13:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			use := Use{yyDollar[2].tok.lexeme, yyDollar[1].tok.line}
+			yyVAL.stmtlist = []Stmt{use}
+			errors.DebugParser(1, true, "use -> USE STR\n")
+		}
+	case 14:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			name := NumAlias{yyDollar[2].tok.lexeme, yyDollar[2].tok.line}
+			c := Const{Name: name, Value: yyDollar[4].arglist[0]}
+			yyVAL.stmtlist = []Stmt{c}
+			errors.DebugParser(1, true, "const -> CONST NUM = arg\n")
+		}
+	case 15:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			name := RegAlias{yyDollar[2].tok.lexeme, yyDollar[2].tok.line}
+			r := RegDecl{Name: name, Value: yyDollar[4].arglist[0]}
+			yyVAL.stmtlist = []Stmt{r}
+			errors.DebugParser(1, true, "regdecl -> REGKW REG = arg\n")
+		}
+	case 16:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			name := RegAlias{yyDollar[2].tok.lexeme, yyDollar[2].tok.line}
+			l := Local{Name: name}
+			yyVAL.stmtlist = []Stmt{l}
+			errors.DebugParser(1, true, "local -> LOCAL REG\n")
+		}
+	case 17:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			errors.DebugParser(1, true, "comment -> CMT\n")
 		}
-	case 10:
+	case 18:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.arglist = make([]Alias, 0, 0)
 			errors.DebugParser(1, true, "args -> EPSILON\n")
 		}
-	case 11:
+	case 19:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.arglist = append(yyDollar[1].arglist, yyDollar[3].arglist...)
 			errors.DebugParser(1, true, "args -> arg, args\n")
 		}
-	case 12:
+	case 20:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.arglist = yyDollar[1].arglist
 			errors.DebugParser(1, true, "args -> arg\n")
 		}
-	case 13:
+	case 21:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.params = paramList{}
+			errors.DebugParser(1, true, "params -> EPSILON\n")
+		}
+	case 22:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.params = paramList{
+				append(yyDollar[1].params.aliases, yyDollar[3].params.aliases...),
+				append(yyDollar[1].params.modes, yyDollar[3].params.modes...),
+			}
+			errors.DebugParser(1, true, "params -> param, params\n")
+		}
+	case 23:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.params = yyDollar[1].params
+			errors.DebugParser(1, true, "params -> param\n")
+		}
+	case 24:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.params = paramList{yyDollar[1].arglist, []Mode{NoMode}}
+			errors.DebugParser(1, true, "param -> arg\n")
+		}
+	case 25:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.params = paramList{yyDollar[2].arglist, []Mode{yyDollar[1].mode}}
+			errors.DebugParser(1, true, "param -> mode arg\n")
+		}
+	case 26:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.mode = In
+			errors.DebugParser(1, true, "mode -> IN\n")
+		}
+	case 27:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.mode = Out
+			errors.DebugParser(1, true, "mode -> OUT\n")
+		}
+	case 28:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.mode = InOut
+			errors.DebugParser(1, true, "mode -> INOUT\n")
+		}
+	case 29:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			reg := RegAlias{yyDollar[1].tok.lexeme, yyDollar[1].tok.line}
 			yyVAL.arglist = []Alias{reg}
 			errors.DebugParser(1, true, "arg -> REG\n")
 		}
-	case 14:
+	case 30:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			num := NumAlias{yyDollar[1].tok.lexeme, yyDollar[1].tok.line}
 			yyVAL.arglist = []Alias{num}
 			errors.DebugParser(1, true, "arg -> NUM\n")
 		}
-	case 15:
+	case 31:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			lit := NumLit{yyDollar[1].tok.lexeme, yyDollar[1].tok.value, yyDollar[1].tok.line}
+			yyVAL.arglist = []Alias{lit}
+			errors.DebugParser(1, true, "arg -> LIT\n")
+		}
+	case 32:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			errors.DebugParser(1, true, "delim -> CR delim\n")
 		}
-	case 16:
+	case 33:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			errors.DebugParser(1, true, "delim -> CR\n")
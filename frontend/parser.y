@@ -19,18 +19,28 @@ func Parse(input string) ([]Stmt, error) {
 	return abstractSyntaxTree, nil
 }
 
+// Params of a decl along with their modes.
+type paramList struct {
+	aliases []Alias
+	modes   []Mode
+}
+
 %}
 
 %union{
 	tok      token
 	arglist  []Alias
 	stmtlist []Stmt
+	params   paramList
+	mode     Mode
 }
 
-%token <tok> CMD REG NUM CMT CR
+%token <tok> CMD REG NUM LIT CMT STR USE CONST REGKW LOCAL IN OUT INOUT CR
 
 %type <arglist> arg args
-%type <stmtlist> decl call stmt program main
+%type <params> param params
+%type <mode> mode
+%type <stmtlist> decl call use const regdecl local stmt program main
 
 %start main
 
@@ -69,17 +79,37 @@ stmt:
 		$$ = $1
 		errors.DebugParser(1, true, "stmt -> call delim\n")
 	}
+|
+	use delim {
+		$$ = $1
+		errors.DebugParser(1, true, "stmt -> use delim\n")
+	}
+|
+	const delim {
+		$$ = $1
+		errors.DebugParser(1, true, "stmt -> const delim\n")
+	}
+|
+	regdecl delim {
+		$$ = $1
+		errors.DebugParser(1, true, "stmt -> regdecl delim\n")
+	}
+|
+	local delim {
+		$$ = $1
+		errors.DebugParser(1, true, "stmt -> local delim\n")
+	}
 |
 	comment delim {
 		errors.DebugParser(1, true, "stmt -> comment delim\n")
 	}
 
 decl:
-	':' CMD args '{' delim program '}' {
+	':' CMD params '{' delim program '}' {
 		cmd := CmdAlias{$2.lexeme, $2.line}
-		decl := Decl{cmd, $3, $6}
+		decl := Decl{Cmd: cmd, Params: $3.aliases, Modes: $3.modes, Body: $6}
 		$$ = []Stmt{decl}
-		errors.DebugParser(1, true, "decl -> :CMD args { delim program }\n")
+		errors.DebugParser(1, true, "decl -> :CMD params { delim program }\n")
 	}
 
 call:
@@ -90,6 +120,37 @@ call:
 		errors.DebugParser(1, true, "call -> CMD args\n")
 	}
 
+use:
+	USE STR {
+		use := Use{$2.lexeme, $1.line}
+		$$ = []Stmt{use}
+		errors.DebugParser(1, true, "use -> USE STR\n")
+	}
+
+const:
+	CONST NUM '=' arg {
+		name := NumAlias{$2.lexeme, $2.line}
+		c := Const{Name: name, Value: $4[0]}
+		$$ = []Stmt{c}
+		errors.DebugParser(1, true, "const -> CONST NUM = arg\n")
+	}
+
+regdecl:
+	REGKW REG '=' arg {
+		name := RegAlias{$2.lexeme, $2.line}
+		r := RegDecl{Name: name, Value: $4[0]}
+		$$ = []Stmt{r}
+		errors.DebugParser(1, true, "regdecl -> REGKW REG = arg\n")
+	}
+
+local:
+	LOCAL REG {
+		name := RegAlias{$2.lexeme, $2.line}
+		l := Local{Name: name}
+		$$ = []Stmt{l}
+		errors.DebugParser(1, true, "local -> LOCAL REG\n")
+	}
+
 comment:
 	CMT {
 		errors.DebugParser(1, true, "comment -> CMT\n")
@@ -111,6 +172,52 @@ args:
 		errors.DebugParser(1, true, "args -> arg\n")
 	}
 
+params:
+	/* nullable */ {
+		$$ = paramList{}
+		errors.DebugParser(1, true, "params -> EPSILON\n")
+	}
+|
+	param ',' params {
+		$$ = paramList{
+			append($1.aliases, $3.aliases...),
+			append($1.modes, $3.modes...),
+		}
+		errors.DebugParser(1, true, "params -> param, params\n")
+	}
+|
+	param {
+		$$ = $1
+		errors.DebugParser(1, true, "params -> param\n")
+	}
+
+param:
+	arg {
+		$$ = paramList{$1, []Mode{NoMode}}
+		errors.DebugParser(1, true, "param -> arg\n")
+	}
+|
+	mode arg {
+		$$ = paramList{$2, []Mode{$1}}
+		errors.DebugParser(1, true, "param -> mode arg\n")
+	}
+
+mode:
+	IN {
+		$$ = In
+		errors.DebugParser(1, true, "mode -> IN\n")
+	}
+|
+	OUT {
+		$$ = Out
+		errors.DebugParser(1, true, "mode -> OUT\n")
+	}
+|
+	INOUT {
+		$$ = InOut
+		errors.DebugParser(1, true, "mode -> INOUT\n")
+	}
+
 arg:
 	REG {
 		reg := RegAlias{$1.lexeme, $1.line}
@@ -123,6 +230,12 @@ arg:
 		$$ = []Alias{num}
 		errors.DebugParser(1, true, "arg -> NUM\n")
 	}
+|
+	LIT {
+		lit := NumLit{$1.lexeme, $1.value, $1.line}
+		$$ = []Alias{lit}
+		errors.DebugParser(1, true, "arg -> LIT\n")
+	}
 
 delim:
 	CR delim {
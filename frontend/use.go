@@ -0,0 +1,236 @@
+package frontend
+
+import (
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ialeinbach/imp/errors"
+	"github.com/ialeinbach/imp/stdlib"
+)
+
+// Prefix of the names of procedures that only the standard library can
+// declare or call, which keeps its helpers from clashing with user code.
+const reservedPrefix = "_"
+
+// Parses a source file along with every file it uses. Paths in use statements
+// are relative to the file they're in, except for names of standard library
+// files, which start with "std/". Each file is loaded once no matter how
+// many files use it, and may only declare procedures and constants. The decls
+// and constants of used files come first, in the order they're loaded, so
+// they're in the global scope before any of the file's own code.
+func ParseFile(filename string, input string) ([]Stmt, error) {
+	key, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, errors.BadSourceFile(filename, err)
+	}
+	ld := &loader{loaded: make(map[string]bool)}
+	prog, err := ld.load(source{filename, key}, input, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(ld.decls, prog...), nil
+}
+
+// A file being loaded.
+type source struct {
+	// Name of the file for errors.
+	name string
+
+	// Absolute path of the file, or its name if it's in the standard
+	// library.
+	key string
+}
+
+// Loads used files.
+type loader struct {
+	// Files that have been loaded, by key.
+	loaded map[string]bool
+
+	// Files being loaded, from the first, for reporting cycles.
+	stack []source
+
+	// Decls and constants of the used files loaded so far.
+	decls []Stmt
+}
+
+// Parses a file, loading the files it uses, and returns its statements other
+// than use statements. Errors in used files name the file they're in.
+func (ld *loader) load(src source, input string, used bool) ([]Stmt, error) {
+	filename := src.name
+	fail := func(line int, err error) error {
+		if used {
+			return errors.New("%s: %s", filename, errors.Line(line, err))
+		}
+		return errors.Line(line, err)
+	}
+
+	ast, err := Parse(input)
+	if err != nil {
+		if used {
+			return nil, errors.New("%s: %s", filename, err)
+		}
+		return nil, err
+	}
+
+	if !strings.HasPrefix(src.key, stdlib.Prefix) {
+		if stmt, ok := findReserved(ast); ok {
+			return nil, fail(stmt.Pos(), errors.New(
+				"%s: names starting with %s are reserved for the standard library", stmt, reservedPrefix,
+			))
+		}
+	}
+
+	ld.loaded[src.key] = true
+	ld.stack = append(ld.stack, src)
+	defer func() {
+		ld.stack = ld.stack[:len(ld.stack)-1]
+	}()
+
+	var prog []Stmt
+	for _, stmt := range ast {
+		switch stmt := stmt.(type) {
+		case Use:
+			if err := ld.use(src, stmt); err != nil {
+				return nil, err
+			}
+		case Decl:
+			if use, ok := findUse(stmt.Body); ok {
+				return nil, fail(use.Pos(), errors.New("use statements must be at the top level"))
+			}
+			if used {
+				ld.decls = append(ld.decls, tagDecl(stmt, filename))
+			} else {
+				prog = append(prog, stmt)
+			}
+		case Const:
+			if used {
+				stmt.file = filename
+				ld.decls = append(ld.decls, stmt)
+			} else {
+				prog = append(prog, stmt)
+			}
+		default:
+			if used {
+				return nil, fail(stmt.Pos(), errors.New("used files may only declare procedures and constants"))
+			}
+			prog = append(prog, stmt)
+		}
+	}
+	return prog, nil
+}
+
+// Loads the file named by a use statement in from, unless it's been loaded
+// already.
+func (ld *loader) use(from source, use Use) error {
+	var (
+		to    source
+		input string
+	)
+	switch {
+	case strings.HasPrefix(use.Path, stdlib.Prefix):
+		to = source{use.Path, use.Path}
+	case strings.HasPrefix(from.key, stdlib.Prefix):
+		// Standard library files are relative to each other.
+		name := path.Join(path.Dir(from.name), use.Path)
+		to = source{name, name}
+	default:
+		name := use.Path
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(from.name), name)
+		}
+		key, err := filepath.Abs(name)
+		if err != nil {
+			return errors.BadSourceFile(name, err)
+		}
+		to = source{name, key}
+	}
+
+	for i, prev := range ld.stack {
+		if prev.key == to.key {
+			var cycle []string
+			for _, src := range ld.stack[i:] {
+				cycle = append(cycle, src.name)
+			}
+			cycle = append(cycle, prev.name)
+			return errors.New("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	if ld.loaded[to.key] {
+		return nil
+	}
+
+	if strings.HasPrefix(to.key, stdlib.Prefix) {
+		src, ok := stdlib.Lookup(to.key)
+		if !ok {
+			return errors.New("%s: %s", from.name, errors.Line(use.Pos(), errors.New(
+				"no standard library file %s (there's %s)", to.key, strings.Join(stdlib.Names(), ", "),
+			)))
+		}
+		input = src
+	} else {
+		src, err := ioutil.ReadFile(to.name)
+		if err != nil {
+			return errors.New("%s: %s", from.name, errors.Line(use.Pos(), errors.BadSourceFile(to.name, err)))
+		}
+		input = string(src)
+	}
+	_, err := ld.load(to, input, true)
+	return err
+}
+
+// Returns the first use statement in stmts or the bodies of their decls.
+func findUse(stmts []Stmt) (Use, bool) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case Use:
+			return stmt, true
+		case Decl:
+			if use, ok := findUse(stmt.Body); ok {
+				return use, true
+			}
+		}
+	}
+	return Use{}, false
+}
+
+// Returns the first call or decl in stmts, or the bodies of their decls, whose
+// procedure has a reserved name.
+func findReserved(stmts []Stmt) (Stmt, bool) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case Call:
+			if strings.HasPrefix(stmt.String(), reservedPrefix) {
+				return stmt, true
+			}
+		case Decl:
+			if strings.HasPrefix(stmt.String(), reservedPrefix) {
+				return stmt, true
+			}
+			if inner, ok := findReserved(stmt.Body); ok {
+				return inner, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Returns a decl marked as coming from a file, along with the decls and
+// constants nested in it.
+func tagDecl(decl Decl, filename string) Decl {
+	decl.file = filename
+	body := make([]Stmt, len(decl.Body))
+	for i, stmt := range decl.Body {
+		switch inner := stmt.(type) {
+		case Decl:
+			stmt = tagDecl(inner, filename)
+		case Const:
+			inner.file = filename
+			stmt = inner
+		}
+		body[i] = stmt
+	}
+	decl.Body = body
+	return decl
+}
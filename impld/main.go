@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ialeinbach/imp/backend"
+	"github.com/ialeinbach/imp/errors"
+)
+
+var (
+	output string
+	arch   string
+)
+
+const (
+	outputUsage string = "file to write the linked program to"
+	archUsage   string = "target to lower the linked program for (defaults to impb for " + backend.BytecodeExt + " files, otherwise amd64-elf)"
+)
+
+func init() {
+	flag.StringVar(&output, "o", "a"+backend.BytecodeExt, outputUsage)
+	flag.StringVar(&arch, "arch", "", archUsage)
+	flag.Parse()
+}
+
+func main() {
+	if flag.NArg() == 0 {
+		flag.PrintDefaults()
+		os.Exit(0)
+	}
+
+	if arch == "" {
+		arch = "amd64-elf"
+		if filepath.Ext(output) == backend.BytecodeExt {
+			arch = "impb"
+		}
+	}
+	target, err := backend.LookupTarget(arch)
+	if err == nil {
+		err = backend.UseTarget(target)
+	}
+	if err != nil {
+		errors.Print(err)
+		os.Exit(1)
+	}
+
+	objs := make([]*backend.Object, flag.NArg())
+	for i, filename := range flag.Args() {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			errors.Print(errors.BadSourceFile(filename, err))
+			os.Exit(1)
+		}
+		if objs[i], err = backend.DecodeObject(data); err != nil {
+			errors.Print(errors.New("%s: %s", filename, err))
+			os.Exit(1)
+		}
+	}
+
+	psuedo, err := backend.Link(objs, flag.Args())
+	if err != nil {
+		errors.Print(err)
+		os.Exit(1)
+	}
+
+	out, err := target.Lower(psuedo)
+	if err != nil {
+		errors.Print(err)
+		os.Exit(1)
+	}
+	perm := os.FileMode(0644)
+	if backend.IsExecutable(target) {
+		perm = 0755
+	}
+	if err := ioutil.WriteFile(output, out, perm); err != nil {
+		errors.Print(errors.BadOutputFile(output, err))
+		os.Exit(1)
+	}
+}
@@ -7,16 +7,22 @@ import (
 	"os"
 
 	"github.com/ialeinbach/imp/errors"
-	"github.com/ialeinbach/imp/frontend"
 	"github.com/ialeinbach/imp/backend"
 )
 
-var interactiveMode bool
+var (
+	interactiveMode bool
+	stackMode       bool
+)
 
-const interactiveModeUsage string = "interpreter blocks on each pseudo-instruction with options for querying internal state"
+const (
+	interactiveModeUsage string = "interpreter blocks on each pseudo-instruction with options for querying internal state"
+	stackModeUsage       string = "print the greatest depth the stack reached after the program returns"
+)
 
 func init() {
 	flag.BoolVar(&interactiveMode, "i", false, interactiveModeUsage)
+	flag.BoolVar(&stackMode, "stack", false, stackModeUsage)
 	flag.Parse()
 }
 
@@ -28,13 +34,7 @@ func main() {
 			os.Exit(1)
 		}
 
-		ast, err := frontend.Parse(string(src))
-		if err != nil {
-			errors.Print(err)
-			os.Exit(1)
-		}
-
-		psuedo, err := backend.Flatten(ast)
+		psuedo, err := backend.Load(filename, src)
 		if err != nil {
 			errors.Print(err)
 			os.Exit(1)
@@ -48,5 +48,8 @@ func main() {
 		}
 
 		fmt.Printf("Imptwerpreter returned successfully with %v.\n", ret)
+		if stackMode {
+			fmt.Printf("Stack depth peaked at %d.\n", imptwerpreter.MaxDepth())
+		}
 	}
 }
@@ -0,0 +1,35 @@
+#!/bin/sh
+# Checks that rec in top-level code calls the top-level code again, rather
+# than whatever else starts at the same label or address, by running programs
+# through twerp.
+#
+# Usage: interp/rec.sh <twerp>
+
+twerp=${1:-./twerp}
+tmp=`mktemp -d`
+trap 'rm -rf $tmp' EXIT
+
+# Checks that a program returns want.
+check() {
+	name=$1 want=$2 prog=$3
+	echo "$prog" > $tmp/prog.imp
+	got=`$twerp $tmp/prog.imp | awk '{ sub(/\.$/, "", $NF); print $NF }'`
+	if [ "$got" != "$want" ]; then
+		echo "$name: want $want, got $got"
+		exit 1
+	fi
+	echo "$name: $got"
+}
+
+check top-level 2 'add #1, @0
+rec #1, @0'
+
+# Procedures are declared before the top-level code is generated, but rec
+# still calls the top-level code.
+check decl-first 2 ':f @x {
+	add #100, @x
+	ret
+}
+
+add #1, @0
+rec #1, @0'
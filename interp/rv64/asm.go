@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var regNames = map[string]uint32{
+	"zero": 0, "ra": 1, "sp": 2, "gp": 3, "tp": 4,
+	"t0": 5, "t1": 6, "t2": 7,
+	"s0": 8, "fp": 8, "s1": 9,
+	"a0": 10, "a1": 11, "a2": 12, "a3": 13, "a4": 14, "a5": 15, "a6": 16, "a7": 17,
+	"s2": 18, "s3": 19, "s4": 20, "s5": 21, "s6": 22, "s7": 23, "s8": 24,
+	"s9": 25, "s10": 26, "s11": 27,
+	"t3": 28, "t4": 29, "t5": 30, "t6": 31,
+}
+
+// A parsed source line that produces machine code.
+type line struct {
+	num  int
+	op   string
+	args []string
+	addr uint64
+}
+
+// Assembles source into RV64I machine code to be loaded at base. Labels,
+// the .text and .globl directives, and # comments are understood.
+func Assemble(src string, base uint64) ([]uint32, error) {
+	var (
+		lines  []line
+		labels = make(map[string]uint64)
+		pc     = base
+	)
+
+	// First pass: collect instructions and assign label addresses.
+	for i, text := range strings.Split(src, "\n") {
+		if c := strings.IndexByte(text, '#'); c >= 0 {
+			text = text[:c]
+		}
+		text = strings.TrimSpace(text)
+		for {
+			c := strings.IndexByte(text, ':')
+			if c < 0 {
+				break
+			}
+			labels[strings.TrimSpace(text[:c])] = pc
+			text = strings.TrimSpace(text[c+1:])
+		}
+		if text == "" || text[0] == '.' {
+			continue
+		}
+
+		l := line{num: i + 1, addr: pc}
+		if sp := strings.IndexAny(text, " \t"); sp >= 0 {
+			l.op = text[:sp]
+			for _, arg := range strings.Split(text[sp:], ",") {
+				l.args = append(l.args, strings.TrimSpace(arg))
+			}
+		} else {
+			l.op = text
+		}
+
+		n, err := size(l)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", l.num, err)
+		}
+		lines = append(lines, l)
+		pc += 4 * uint64(n)
+	}
+
+	// Second pass: encode.
+	var code []uint32
+	for _, l := range lines {
+		enc, err := encode(l, labels)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", l.num, err)
+		}
+		code = append(code, enc...)
+	}
+	return code, nil
+}
+
+// Returns the number of machine instructions a line assembles to.
+func size(l line) (int, error) {
+	if l.op != "li" {
+		return 1, nil
+	}
+	if len(l.args) != 2 {
+		return 0, fmt.Errorf("li expects 2 operands")
+	}
+	imm, err := strconv.ParseInt(l.args[1], 0, 64)
+	if err != nil {
+		return 0, err
+	}
+	return len(li(0, imm)), nil
+}
+
+func encode(l line, labels map[string]uint64) ([]uint32, error) {
+	a := operands{args: l.args, labels: labels, pc: l.addr}
+	one := func(ins uint32) ([]uint32, error) {
+		if a.err != nil {
+			return nil, a.err
+		}
+		return []uint32{ins}, nil
+	}
+
+	switch l.op {
+	case "li":
+		rd := a.reg(0)
+		imm := a.imm(1)
+		if a.err != nil {
+			return nil, a.err
+		}
+		return li(rd, imm), nil
+	case "mv":
+		return one(iType(0x13, 0, a.reg(0), a.reg(1), 0))
+	case "add":
+		return one(rType(0x33, 0, 0x00, a.reg(0), a.reg(1), a.reg(2)))
+	case "sub":
+		return one(rType(0x33, 0, 0x20, a.reg(0), a.reg(1), a.reg(2)))
+	case "addi":
+		return one(iType(0x13, 0, a.reg(0), a.reg(1), a.imm(2)))
+	case "ld":
+		off, base := a.mem(1)
+		return one(iType(0x03, 3, a.reg(0), base, off))
+	case "sd":
+		off, base := a.mem(1)
+		return one(sType(0x23, 3, base, a.reg(0), off))
+	case "bne":
+		return one(bType(0x63, 1, a.reg(0), a.reg(1), a.target(2, 13)))
+	case "beq":
+		return one(bType(0x63, 0, a.reg(0), a.reg(1), a.target(2, 13)))
+	case "j":
+		return one(jType(0x6f, 0, a.target(0, 21)))
+	case "jal":
+		return one(jType(0x6f, a.reg(0), a.target(1, 21)))
+	case "ret":
+		return one(iType(0x67, 0, 0, 1, 0))
+	case "ecall":
+		return one(0x73)
+	}
+	return nil, fmt.Errorf("unsupported instruction: %s", l.op)
+}
+
+// Operand parser that records the first error it encounters so encodings can
+// be written as straight-line expressions.
+type operands struct {
+	args   []string
+	labels map[string]uint64
+	pc     uint64
+	err    error
+}
+
+func (a *operands) arg(i int) string {
+	if i >= len(a.args) {
+		if a.err == nil {
+			a.err = fmt.Errorf("missing operand %d", i+1)
+		}
+		return ""
+	}
+	return a.args[i]
+}
+
+func (a *operands) reg(i int) uint32 {
+	name := a.arg(i)
+	if r, ok := regNames[name]; ok {
+		return r
+	}
+	if strings.HasPrefix(name, "x") {
+		if r, err := strconv.Atoi(name[1:]); err == nil && r >= 0 && r < 32 {
+			return uint32(r)
+		}
+	}
+	if a.err == nil {
+		a.err = fmt.Errorf("bad register: %q", name)
+	}
+	return 0
+}
+
+func (a *operands) imm(i int) int64 {
+	imm, err := strconv.ParseInt(a.arg(i), 0, 64)
+	if err != nil && a.err == nil {
+		a.err = err
+	}
+	return imm
+}
+
+// Parses an off(reg) memory operand.
+func (a *operands) mem(i int) (int64, uint32) {
+	text := a.arg(i)
+	open, close := strings.IndexByte(text, '('), strings.IndexByte(text, ')')
+	if open < 0 || close < open {
+		if a.err == nil {
+			a.err = fmt.Errorf("bad memory operand: %q", text)
+		}
+		return 0, 0
+	}
+	sub := operands{args: []string{text[:open], text[open+1 : close]}}
+	off, base := sub.imm(0), sub.reg(1)
+	if sub.err != nil && a.err == nil {
+		a.err = sub.err
+	}
+	return off, base
+}
+
+// Returns the pc-relative offset of a label operand, which must fit in a
+// signed immediate of the given width.
+func (a *operands) target(i int, bits uint) int64 {
+	name := a.arg(i)
+	addr, ok := a.labels[name]
+	if !ok && a.err == nil {
+		a.err = fmt.Errorf("undefined label: %s", name)
+	}
+	off := int64(addr - a.pc)
+	if limit := int64(1) << (bits - 1); (off < -limit || off >= limit) && a.err == nil {
+		a.err = fmt.Errorf("label %s out of range", name)
+	}
+	return off
+}
+
+// Expands li into lui/addiw/slli/addi sequences.
+func li(rd uint32, imm int64) []uint32 {
+	if imm >= -2048 && imm < 2048 {
+		return []uint32{iType(0x13, 0, rd, 0, imm)}
+	}
+	if int64(int32(imm)) == imm {
+		hi := (imm + 0x800) >> 12
+		lo := imm - hi<<12
+		out := []uint32{uType(0x37, rd, hi)}
+		if lo != 0 {
+			out = append(out, iType(0x1b, 0, rd, rd, lo))
+		}
+		return out
+	}
+	lo := imm << 52 >> 52
+	hi := (imm - lo) >> 12
+	out := append(li(rd, hi), iType(0x13, 1, rd, rd, 12))
+	if lo != 0 {
+		out = append(out, iType(0x13, 0, rd, rd, lo))
+	}
+	return out
+}
+
+//
+// Instruction formats
+//
+
+func rType(op, funct3, funct7, rd, rs1, rs2 uint32) uint32 {
+	return funct7<<25 | rs2<<20 | rs1<<15 | funct3<<12 | rd<<7 | op
+}
+
+func iType(op, funct3, rd, rs1 uint32, imm int64) uint32 {
+	return uint32(imm&0xfff)<<20 | rs1<<15 | funct3<<12 | rd<<7 | op
+}
+
+func sType(op, funct3, rs1, rs2 uint32, imm int64) uint32 {
+	i := uint32(imm & 0xfff)
+	return (i>>5)<<25 | rs2<<20 | rs1<<15 | funct3<<12 | (i&0x1f)<<7 | op
+}
+
+func bType(op, funct3, rs1, rs2 uint32, off int64) uint32 {
+	i := uint32(off & 0x1fff)
+	return (i>>12&1)<<31 | (i>>5&0x3f)<<25 | rs2<<20 | rs1<<15 |
+		funct3<<12 | (i>>1&0xf)<<8 | (i>>11&1)<<7 | op
+}
+
+func uType(op, rd uint32, imm int64) uint32 {
+	return uint32(imm&0xfffff)<<12 | rd<<7 | op
+}
+
+func jType(op, rd uint32, off int64) uint32 {
+	i := uint32(off & 0x1fffff)
+	return (i>>20&1)<<31 | (i>>1&0x3ff)<<21 | (i>>11&1)<<20 |
+		(i>>12&0xff)<<12 | rd<<7 | op
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// Address the program is loaded at.
+	TextBase uint64 = 0x10000
+
+	// Size of the memory below the stack pointer given to the program.
+	stackSize uint64 = 1 << 20
+
+	// Linux system call number for exit.
+	sysExit uint64 = 93
+)
+
+// An RV64I hart with flat memory holding the program followed by the stack.
+type Machine struct {
+	X   [32]uint64
+	PC  uint64
+	Mem []byte
+
+	// Number of instructions executed so far.
+	Steps int
+}
+
+// Returns a machine with code loaded at TextBase, the pc pointing at the
+// first instruction and sp at the top of the stack.
+func NewMachine(code []uint32) *Machine {
+	m := &Machine{
+		PC:  TextBase,
+		Mem: make([]byte, TextBase+uint64(4*len(code))+stackSize),
+	}
+	for i, ins := range code {
+		binary.LittleEndian.PutUint32(m.Mem[TextBase+uint64(4*i):], ins)
+	}
+	m.X[2] = uint64(len(m.Mem))
+	return m
+}
+
+// Runs until the program makes an exit system call and returns its status.
+// Execution stops with an error after limit instructions unless limit <= 0.
+func (m *Machine) Run(limit int) (int64, error) {
+	for limit <= 0 || m.Steps < limit {
+		exited, err := m.Step()
+		if err != nil {
+			return 0, fmt.Errorf("pc %#x: %s", m.PC, err)
+		}
+		if exited {
+			return int64(m.X[10]), nil
+		}
+	}
+	return 0, fmt.Errorf("step limit of %d reached", limit)
+}
+
+// Executes a single instruction. Reports whether it was an exit system call.
+func (m *Machine) Step() (exited bool, err error) {
+	ins, err := m.load(m.PC, 4)
+	if err != nil {
+		return false, err
+	}
+	m.Steps++
+
+	var (
+		op     = uint32(ins) & 0x7f
+		rd     = uint32(ins) >> 7 & 0x1f
+		funct3 = uint32(ins) >> 12 & 0x7
+		rs1    = m.X[uint32(ins)>>15&0x1f]
+		rs2    = m.X[uint32(ins)>>20&0x1f]
+		funct7 = uint32(ins) >> 25
+		next   = m.PC + 4
+		out    uint64
+	)
+
+	switch op {
+	case 0x37: // LUI
+		out = immU(uint32(ins))
+	case 0x17: // AUIPC
+		out = m.PC + immU(uint32(ins))
+	case 0x6f: // JAL
+		out, next = next, m.PC+immJ(uint32(ins))
+	case 0x67: // JALR
+		out, next = next, (rs1+immI(uint32(ins)))&^1
+	case 0x63: // BRANCH
+		var taken bool
+		switch funct3 {
+		case 0:
+			taken = rs1 == rs2
+		case 1:
+			taken = rs1 != rs2
+		case 4:
+			taken = int64(rs1) < int64(rs2)
+		case 5:
+			taken = int64(rs1) >= int64(rs2)
+		case 6:
+			taken = rs1 < rs2
+		case 7:
+			taken = rs1 >= rs2
+		default:
+			return false, illegal(ins)
+		}
+		if taken {
+			next = m.PC + immB(uint32(ins))
+		}
+		rd = 0
+	case 0x03: // LOAD
+		addr := rs1 + immI(uint32(ins))
+		width := uint64(1) << (funct3 & 3)
+		if funct3 == 7 {
+			return false, illegal(ins)
+		}
+		if out, err = m.load(addr, width); err != nil {
+			return false, err
+		}
+		if funct3 < 4 {
+			shift := 64 - 8*width
+			out = uint64(int64(out<<shift) >> shift)
+		}
+	case 0x23: // STORE
+		if funct3 > 3 {
+			return false, illegal(ins)
+		}
+		if err = m.store(rs1+immS(uint32(ins)), 1<<funct3, rs2); err != nil {
+			return false, err
+		}
+		rd = 0
+	case 0x13, 0x33: // OP-IMM, OP
+		b := immI(uint32(ins))
+		if op == 0x33 {
+			b = rs2
+		}
+		if out, err = alu(op, funct3, funct7, rs1, b); err != nil {
+			return false, illegal(ins)
+		}
+	case 0x1b, 0x3b: // OP-IMM-32, OP-32
+		b := immI(uint32(ins))
+		if op == 0x3b {
+			b = rs2
+		}
+		if out, err = alu32(op, funct3, funct7, rs1, b); err != nil {
+			return false, illegal(ins)
+		}
+	case 0x0f: // FENCE
+		rd = 0
+	case 0x73: // SYSTEM
+		if ins != 0x73 {
+			return false, illegal(ins)
+		}
+		if m.X[17] != sysExit {
+			return false, fmt.Errorf("unsupported system call %d", m.X[17])
+		}
+		return true, nil
+	default:
+		return false, illegal(ins)
+	}
+
+	if rd != 0 {
+		m.X[rd] = out
+	}
+	m.PC = next
+	return false, nil
+}
+
+// Computes OP and OP-IMM results. For OP-IMM, b is the sign-extended
+// immediate and funct7 only matters for shifts.
+func alu(op, funct3, funct7 uint32, a, b uint64) (uint64, error) {
+	shamt := b & 0x3f
+	switch funct3 {
+	case 0:
+		if op == 0x33 && funct7 == 0x20 {
+			return a - b, nil
+		}
+		return a + b, nil
+	case 1:
+		return a << shamt, nil
+	case 2:
+		if int64(a) < int64(b) {
+			return 1, nil
+		}
+		return 0, nil
+	case 3:
+		if a < b {
+			return 1, nil
+		}
+		return 0, nil
+	case 4:
+		return a ^ b, nil
+	case 5:
+		if funct7>>1 == 0x10 {
+			return uint64(int64(a) >> shamt), nil
+		}
+		return a >> shamt, nil
+	case 6:
+		return a | b, nil
+	case 7:
+		return a & b, nil
+	}
+	return 0, fmt.Errorf("bad funct3")
+}
+
+// Computes OP-32 and OP-IMM-32 results, sign-extended from 32 bits.
+func alu32(op, funct3, funct7 uint32, a, b uint64) (uint64, error) {
+	var (
+		x, y  = uint32(a), uint32(b)
+		shamt = y & 0x1f
+		out   uint32
+	)
+	switch funct3 {
+	case 0:
+		if op == 0x3b && funct7 == 0x20 {
+			out = x - y
+		} else {
+			out = x + y
+		}
+	case 1:
+		out = x << shamt
+	case 5:
+		if funct7 == 0x20 {
+			out = uint32(int32(x) >> shamt)
+		} else {
+			out = x >> shamt
+		}
+	default:
+		return 0, fmt.Errorf("bad funct3")
+	}
+	return uint64(int64(int32(out))), nil
+}
+
+func (m *Machine) load(addr, width uint64) (uint64, error) {
+	if addr+width > uint64(len(m.Mem)) || addr+width < addr {
+		return 0, fmt.Errorf("load from %#x out of bounds", addr)
+	}
+	var buf [8]byte
+	copy(buf[:], m.Mem[addr:addr+width])
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+func (m *Machine) store(addr, width, val uint64) error {
+	if addr+width > uint64(len(m.Mem)) || addr+width < addr {
+		return fmt.Errorf("store to %#x out of bounds", addr)
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], val)
+	copy(m.Mem[addr:addr+width], buf[:width])
+	return nil
+}
+
+func illegal(ins uint64) error {
+	return fmt.Errorf("illegal instruction %#08x", ins)
+}
+
+//
+// Immediate decoding
+//
+
+func immI(ins uint32) uint64 {
+	return uint64(int64(int32(ins) >> 20))
+}
+
+func immS(ins uint32) uint64 {
+	return uint64(int64(int32(ins)>>25<<5 | int32(ins>>7&0x1f)))
+}
+
+func immB(ins uint32) uint64 {
+	imm := int32(ins)>>31<<12 | int32(ins>>7&1)<<11 |
+		int32(ins>>25&0x3f)<<5 | int32(ins>>8&0xf)<<1
+	return uint64(int64(imm))
+}
+
+func immU(ins uint32) uint64 {
+	return uint64(int64(int32(ins &^ 0xfff)))
+}
+
+func immJ(ins uint32) uint64 {
+	imm := int32(ins)>>31<<20 | int32(ins>>12&0xff)<<12 |
+		int32(ins>>20&1)<<11 | int32(ins>>21&0x3ff)<<1
+	return uint64(int64(imm))
+}
@@ -0,0 +1,49 @@
+// Command rv64 is a small RV64I emulator that make test uses to check the
+// output of the riscv64 target without RISC-V hardware. It assembles the
+// subset of GNU assembler syntax that the target emits, runs the resulting
+// machine code and prints the status the program exits with.
+//
+// Usage: imp -arch riscv64 <file> | interp/rv64/rv64
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+func main() {
+	flag.Parse()
+
+	filenames := flag.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	for _, filename := range filenames {
+		var src []byte
+		var err error
+		if filename == "-" {
+			src, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			src, err = ioutil.ReadFile(filename)
+		}
+		if err != nil {
+			errors.Print(errors.BadSourceFile(filename, err))
+			os.Exit(1)
+		}
+
+		code, err := Assemble(string(src), TextBase)
+		if err == nil {
+			var status int64
+			if status, err = NewMachine(code).Run(0); err == nil {
+				fmt.Println(status)
+				continue
+			}
+		}
+		errors.Print(errors.New("%s: %s", filename, err))
+		os.Exit(1)
+	}
+}
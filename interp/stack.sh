@@ -0,0 +1,62 @@
+#!/bin/sh
+# Checks that loops written with rec run in constant stack space, by running
+# each loop through twerp for 10 and a million iterations and comparing how
+# deep the stack got.
+#
+# Usage: interp/stack.sh <twerp>
+
+twerp=${1:-./twerp}
+tmp=`mktemp -d`
+trap 'rm -rf $tmp' EXIT
+
+# Prints the result and peak stack depth of a program.
+run() {
+	$twerp -stack $tmp/prog.imp | awk '{ sub(/\.$/, "", $NF); printf "%s ", $NF }'
+}
+
+# Checks that a loop computes want into @0 for @1 = n, and that the stack
+# depth doesn't depend on n.
+check() {
+	name=$1 prog=$2
+	for n in 10 1000000; do
+		{
+			echo "$prog"
+			echo "mov #$n, @1"
+			echo "mov #1, @2"
+			echo "$name @1, @2, @0"
+		} > $tmp/prog.imp
+		set -- `run`
+		if [ "$1" != "$n" ]; then
+			echo "$name: want $n for @1 = $n, got $1"
+			exit 1
+		fi
+		if [ -n "$depth" ] && [ "$2" != "$depth" ]; then
+			echo "$name: stack depth was $depth for 10 iterations but $2 for $n"
+			exit 1
+		fi
+		depth=$2
+	done
+	echo "$name: stack depth $depth"
+	depth=
+}
+
+check count ':count @n, in @d, out @r {
+	local @t
+
+	mov #0, @r
+	mov @n, @t
+	count_loop @t, @d, @r
+	ret
+}
+
+:count_loop @t, in @d, @r {
+	ret #0, @t
+
+	sub @d, @t
+	add @d, @r
+
+	rec
+	ret
+}'
+
+check mul 'use "std/math"'
@@ -14,6 +14,33 @@ import (
 // Represents a twerp instruction.
 type ins func(*twerp,[]backend.Psuedo) error
 
+// Implementation of each opcode.
+var twerpIns = map[backend.Opcode]ins{
+	backend.MoveI: (*twerp).MoveI,
+	backend.MoveR: (*twerp).MoveR,
+	backend.AddI:  (*twerp).AddI,
+	backend.AddR:  (*twerp).AddR,
+	backend.SubI:  (*twerp).SubI,
+	backend.SubR:  (*twerp).SubR,
+	backend.BneI:  (*twerp).BneI,
+	backend.BneR:  (*twerp).BneR,
+	backend.JumpI: (*twerp).JumpI,
+	backend.CallI: (*twerp).CallI,
+	backend.TailI: (*twerp).JumpI,
+	backend.Ret:   (*twerp).Ret,
+	backend.PushI: (*twerp).PushI,
+	backend.PushR: (*twerp).PushR,
+	backend.PopR:  (*twerp).PopR,
+}
+
+func init() {
+	for _, op := range backend.Opcodes() {
+		if twerpIns[op] == nil {
+			panic("twerp: no implementation of " + op.String())
+		}
+	}
+}
+
 const twerpUsage string = `Commands:
 "h", "help": print this message
 "n", "next": execute an instruction
@@ -30,6 +57,9 @@ type twerp struct {
 	stack []int64
 	prog  []backend.Ins
 	ip    int64
+
+	// Greatest number of values the stack has held.
+	depth int
 }
 
 func NewTwerp(prog []backend.Ins, regs int) *twerp {
@@ -108,6 +138,14 @@ func (t *twerp) pop() (i int64, err error) {
 
 func (t *twerp) push(i int64) {
 	t.stack = append(t.stack, i)
+	if len(t.stack) > t.depth {
+		t.depth = len(t.stack)
+	}
+}
+
+// Returns the greatest number of values the stack has held.
+func (t *twerp) MaxDepth() int {
+	return t.depth
 }
 
 // Executes loaded program.
@@ -151,25 +189,12 @@ InteractLoop:
 			}
 		}
 
-		switch fetched = t.fetch(); fetched.Name {
-		case "MOVE_I": decoded = (*twerp).MoveI
-		case "MOVE_R": decoded = (*twerp).MoveR
-		case "ADD_I":  decoded = (*twerp).AddI
-		case "ADD_R":  decoded = (*twerp).AddR
-		case "SUB_I":  decoded = (*twerp).SubI
-		case "SUB_R":  decoded = (*twerp).SubR
-		case "RET":    decoded = (*twerp).Ret
-		case "JUMP_I": decoded = (*twerp).JumpI
-		case "CALL_I": decoded = (*twerp).CallI
-		case "PUSH_R": decoded = (*twerp).PushR
-		case "POP_R":  decoded = (*twerp).PopR
-		case "BNE_R":  decoded = (*twerp).BneR
-		case "BNE_I":  decoded = (*twerp).BneI
-		default:
-			return t.ret(), errors.New("fetched not recognized: " + fetched.Name)
+		fetched = t.fetch()
+		if decoded = twerpIns[fetched.Op]; decoded == nil {
+			return t.ret(), errors.New("fetched not recognized: " + fetched.Op.String())
 		}
 		if err := decoded(t, fetched.Args); err != nil {
-			return t.ret(), errors.New("error executing " + fetched.Name + ": " + err.Error())
+			return t.ret(), errors.New("error executing " + fetched.Op.String() + ": " + err.Error())
 		}
 	}
 	return t.ret(), nil
@@ -4,14 +4,20 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ialeinbach/imp/frontend"
 	"github.com/ialeinbach/imp/backend"
 	"github.com/ialeinbach/imp/errors"
 )
 
+// Target used when writing to a file without an explicit -arch.
+const defaultOutputTarget string = "amd64-elf"
+
 const (
 	// Lexer Verbosity: -lexer-verbosity, -lv
 	lexerVerbosityUsage     string = "level of lexer debugging information to print"
@@ -25,6 +31,21 @@ const (
 	// Target Architecture: -target-architecture, -arch
 	targetArchitectureUsage string = "target architecture for code generation"
 
+	// Emit: -emit
+	emitUsage               string = "source-level target to emit code for (an alternative to -arch, e.g. -emit=c)"
+
+	// Go Package: -go-package
+	goPackageUsage          string = "package name used by -emit=go"
+
+	// Output: -output, -o
+	outputUsage             string = "write lowered program to a file instead of stdout (defaults -arch to " + defaultOutputTarget + ", or impb for " + backend.BytecodeExt + " files)"
+
+	// Compile Only: -c
+	compileOnlyUsage        string = "compile each file to an object file (" + backend.ObjectExt + ") to be linked by impld"
+
+	// List Targets: -list-targets
+	listTargetsUsage        string = "list available target architectures and exit"
+
 	// Help: -help, -h
 	helpUsage               string = "print help information"
 )
@@ -46,6 +67,22 @@ func init() {
 	flag.StringVar(&targetArchitectureLong, "target-architechture", "", targetArchitectureUsage)
 	flag.StringVar(&targetArchitectureShort, "arch", "", targetArchitectureUsage)
 
+	var emit string
+	flag.StringVar(&emit, "emit", "", emitUsage)
+
+	var goPackage string
+	flag.StringVar(&goPackage, "go-package", backend.GoPackageFlag, goPackageUsage)
+
+	var outputLong, outputShort string
+	flag.StringVar(&outputLong, "output", "", outputUsage)
+	flag.StringVar(&outputShort, "o", "", outputUsage)
+
+	var compileOnly bool
+	flag.BoolVar(&compileOnly, "c", false, compileOnlyUsage)
+
+	var listTargets bool
+	flag.BoolVar(&listTargets, "list-targets", false, listTargetsUsage)
+
 	var helpLong, helpShort bool
 	flag.BoolVar(&helpLong, "help", false, helpUsage)
 	flag.BoolVar(&helpShort, "h", false, helpUsage)
@@ -55,20 +92,83 @@ func init() {
 	configLexerVerbosity(lexerVerbosityLong, lexerVerbosityShort)
 	configParserVerbosity(parserVerbosityLong, parserVerbosityShort)
 	configTargetArchitecture(targetArchitectureLong, targetArchitectureShort)
+	configEmit(emit)
+	configGoPackage(goPackage)
 	configBackendVerbosity(backendVerbosityLong, backendVerbosityShort)
+	configOutput(outputLong, outputShort)
+	configCompileOnly(compileOnly)
+	configListTargets(listTargets)
 	configHelp(helpLong, helpShort)
 }
 
 // Flag-configurables.
 var (
-	HelpFlag bool
+	EmitFlag        string
+	OutputFlag      string
+	CompileOnlyFlag bool
+	ListTargetsFlag bool
+	HelpFlag        bool
 )
 
 func main() {
+	if ListTargetsFlag {
+		for _, t := range backend.Targets() {
+			fmt.Printf("%-12s registers: %d, max arguments: %d\n",
+				t.Name(), t.RegCount(), t.ArgCount())
+		}
+		os.Exit(0)
+	}
 	if flag.NArg() == 0 || HelpFlag {
 		flag.PrintDefaults()
 		os.Exit(0)
 	}
+
+	if OutputFlag != "" && flag.NArg() > 1 {
+		errors.Print(errors.New("-o can't be used with multiple source files"))
+		os.Exit(1)
+	}
+	if CompileOnlyFlag {
+		if backend.TargetArchitectureFlag != "" || EmitFlag != "" {
+			errors.Print(errors.New("-c can't be used with -arch or -emit"))
+			os.Exit(1)
+		}
+		for _, filename := range flag.Args() {
+			if err := compileObject(filename); err != nil {
+				errors.Print(err)
+				os.Exit(1)
+			}
+		}
+		os.Exit(0)
+	}
+	if EmitFlag != "" {
+		if backend.TargetArchitectureFlag != "" && backend.TargetArchitectureFlag != EmitFlag {
+			errors.Print(errors.New("-arch and -emit select different targets"))
+			os.Exit(1)
+		}
+		backend.TargetArchitectureFlag = EmitFlag
+	}
+	if OutputFlag != "" && backend.TargetArchitectureFlag == "" {
+		if filepath.Ext(OutputFlag) == backend.BytecodeExt {
+			backend.TargetArchitectureFlag = "impb"
+		} else {
+			backend.TargetArchitectureFlag = defaultOutputTarget
+		}
+	}
+
+	// Without -arch, -emit or -o, the compiler only checks the program.
+	var target backend.Target
+	if backend.TargetArchitectureFlag != "" {
+		t, err := backend.LookupTarget(backend.TargetArchitectureFlag)
+		if err == nil {
+			err = backend.UseTarget(t)
+		}
+		if err != nil {
+			errors.Print(err)
+			os.Exit(1)
+		}
+		target = t
+	}
+
 	for _, filename := range flag.Args() {
 		src, err := ioutil.ReadFile(filename)
 		if err != nil {
@@ -76,21 +176,67 @@ func main() {
 			os.Exit(1)
 		}
 
-		ast, err := frontend.Parse(string(src))
+		psuedo, err := backend.Load(filename, src)
 		if err != nil {
 			errors.Print(err)
 			os.Exit(1)
 		}
 
-		_, err = backend.Flatten(ast)
+		// By default, the compiler outputs nothing. However, with the various
+		// verbosity flags, each stage can be printed out.
+		if target == nil {
+			errors.Ok(filename)
+			continue
+		}
+
+		out, err := target.Lower(psuedo)
 		if err != nil {
 			errors.Print(err)
 			os.Exit(1)
 		}
+		if OutputFlag == "" {
+			os.Stdout.Write(out)
+			continue
+		}
 
-		// Currently, by default, the compiler outputs nothing.
-		// However, with the various verbosity flags, each stage can be printed
-		// out.
-		errors.Ok(filename)
+		perm := os.FileMode(0644)
+		if backend.IsExecutable(target) {
+			perm = 0755
+		}
+		if err := ioutil.WriteFile(OutputFlag, out, perm); err != nil {
+			errors.Print(errors.BadOutputFile(OutputFlag, err))
+			os.Exit(1)
+		}
+	}
+}
+
+// Compiles a source file to an object file, written to -o or to the source
+// file with its extension replaced.
+func compileObject(filename string) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.BadSourceFile(filename, err)
+	}
+
+	ast, err := frontend.ParseFile(filename, string(src))
+	if err != nil {
+		return err
+	}
+	obj, err := backend.CompileObject(ast)
+	if err != nil {
+		return err
+	}
+	out, err := backend.EncodeObject(obj)
+	if err != nil {
+		return err
+	}
+
+	output := OutputFlag
+	if output == "" {
+		output = strings.TrimSuffix(filename, filepath.Ext(filename)) + backend.ObjectExt
+	}
+	if err := ioutil.WriteFile(output, out, 0644); err != nil {
+		return errors.BadOutputFile(output, err)
 	}
+	return nil
 }
@@ -0,0 +1,96 @@
+#!/bin/sh
+# Runs every procedure of the standard library through twerp across a range
+# of inputs. Each program adds the inputs and registers @4 to @7 to the
+# result, so that clobbered inputs and locals that aren't restored are caught
+# too.
+#
+# Usage: stdlib/check.sh <twerp>
+
+twerp=${1:-./twerp}
+tmp=`mktemp -d`
+trap 'rm -rf $tmp' EXIT
+
+gcd() {
+	a=$1 b=$2
+	while [ $b -ne 0 ]; do
+		t=$(( a % b )) a=$b b=$t
+	done
+	echo $a
+}
+
+pow() {
+	r=1 e=$2
+	while [ $e -gt 0 ]; do
+		r=$(( r * $1 )) e=$(( e - 1 ))
+	done
+	echo $r
+}
+
+fct() {
+	r=1 n=$1
+	while [ $n -gt 1 ]; do
+		r=$(( r * n )) n=$(( n - 1 ))
+	done
+	echo $r
+}
+
+# Checks that a call computes want into @0, with x in @1 and y in @2.
+check() {
+	call=$1 x=$2 y=$3 want=$(( $4 + $2 + $3 + 4 * 1000 ))
+	{
+		echo 'use "std/math"'
+		echo "mov #$x, @1"
+		echo "mov #$y, @2"
+		for r in 4 5 6 7; do echo "mov #1000, @$r"; done
+		echo "$call"
+		for r in 1 2 4 5 6 7; do echo "add @$r, @0"; done
+	} > $tmp/prog.imp
+	got=`$twerp $tmp/prog.imp | awk '{ sub(/\.$/, "", $NF); print $NF }'`
+	if [ "$got" != "$want" ]; then
+		echo "std/math: $call with @1 = $x and @2 = $y: want $want, got $got"
+		exit 1
+	fi
+}
+
+for x in 0 1 2 3 5 8 13; do
+	for y in 0 1 2 3 5 8 13; do
+		check "mul @1, @2, @0" $x $y $(( x * y ))
+		check "gcd @1, @2, @0" $x $y `gcd $x $y`
+		check "min @1, @2, @0" $x $y $(( x < y ? x : y ))
+		check "max @1, @2, @0" $x $y $(( x > y ? x : y ))
+		if [ $y -gt 0 ]; then
+			check "div @1, @2, @0" $x $y $(( x / y ))
+			check "divmod @1, @2, @0, @3" $x $y $(( x / y ))
+			check "divmod @1, @2, @3, @0" $x $y $(( x % y ))
+			check "mod @1, @2, @0" $x $y $(( x % y ))
+		fi
+		if [ $y -le 3 ]; then
+			check "pow @1, @2, @0" $x $y `pow $x $y`
+		fi
+	done
+done
+for n in 0 1 2 3 4 5 6; do
+	check "fct @1, @0" $n 0 `fct $n`
+done
+
+# The library's helpers have reserved names, so files using it can declare
+# procedures named like them.
+cat > $tmp/prog.imp <<EOF
+use "std/math"
+
+:mul_loop @x {
+	add #1, @x
+	ret
+}
+
+mov #6, @1
+mov #7, @2
+mul @1, @2, @0
+mul_loop @0
+EOF
+got=`$twerp $tmp/prog.imp | awk '{ sub(/\.$/, "", $NF); print $NF }'`
+if [ "$got" != 43 ]; then
+	echo "std/math: declaring mul_loop alongside the library: want 43, got $got"
+	exit 1
+fi
+echo "std/math: ok"
@@ -0,0 +1,40 @@
+// Package stdlib is imp's standard library. Its files are embedded in the
+// binaries, and programs use them by name, e.g. use "std/math".
+package stdlib
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Prefix of the names of standard library files.
+const Prefix = "std/"
+
+//go:embed *.imp
+var files embed.FS
+
+// Returns the source of the standard library file with a name, e.g.
+// "std/math".
+func Lookup(name string) (string, bool) {
+	if !strings.HasPrefix(name, Prefix) || path.Ext(name) != "" {
+		return "", false
+	}
+	src, err := files.ReadFile(strings.TrimPrefix(name, Prefix) + ".imp")
+	if err != nil {
+		return "", false
+	}
+	return string(src), true
+}
+
+// Returns the names of every standard library file.
+func Names() []string {
+	entries, _ := files.ReadDir(".")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, Prefix+strings.TrimSuffix(entry.Name(), ".imp"))
+	}
+	sort.Strings(names)
+	return names
+}
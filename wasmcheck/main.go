@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Validates WebAssembly modules generated with -arch wasm, reading them from
+// the files given or from stdin when there are none.
+func main() {
+	flag.Parse()
+
+	filenames := flag.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	for _, filename := range filenames {
+		var data []byte
+		var err error
+		if filename == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(filename)
+		}
+		if err != nil {
+			errors.Print(errors.BadSourceFile(filename, err))
+			os.Exit(1)
+		}
+		if err := validate(data); err != nil {
+			errors.Print(errors.New("%s: %s", filename, err))
+			os.Exit(1)
+		}
+	}
+}
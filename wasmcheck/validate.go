@@ -0,0 +1,744 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/ialeinbach/imp/errors"
+)
+
+// Parts of the WebAssembly binary format. They're spelled out here rather than
+// shared with the wasm target, so that a mistake in one doesn't hide in the
+// other.
+const (
+	secType     byte = 1
+	secFunction byte = 3
+	secMemory   byte = 5
+	secGlobal   byte = 6
+	secExport   byte = 7
+	secCode     byte = 10
+
+	funcForm  byte = 0x60
+	blockVoid byte = 0x40
+
+	exportFunc   byte = 0x00
+	exportMemory byte = 0x02
+	exportGlobal byte = 0x03
+
+	opUnreachable byte = 0x00
+	opNop         byte = 0x01
+	opBlock       byte = 0x02
+	opLoop        byte = 0x03
+	opIf          byte = 0x04
+	opElse        byte = 0x05
+	opEnd         byte = 0x0b
+	opBr          byte = 0x0c
+	opBrIf        byte = 0x0d
+	opBrTable     byte = 0x0e
+	opReturn      byte = 0x0f
+	opCall        byte = 0x10
+	opDrop        byte = 0x1a
+	opLocalGet    byte = 0x20
+	opLocalSet    byte = 0x21
+	opLocalTee    byte = 0x22
+	opGlobalGet   byte = 0x23
+	opGlobalSet   byte = 0x24
+	opI32Load     byte = 0x28
+	opI64Load     byte = 0x29
+	opI32Store    byte = 0x36
+	opI64Store    byte = 0x37
+	opI32Const    byte = 0x41
+	opI64Const    byte = 0x42
+	opI32Eqz      byte = 0x45
+	opI64Eqz      byte = 0x50
+)
+
+// Validates a WebAssembly module by decoding it, without a wasm runtime. It
+// checks the parts of the binary format the wasm target uses: the header,
+// the order and sizes of sections, the types, functions, memories, globals
+// and exports they declare, and the code of every function, which is type
+// checked as a runtime would before instantiating the module. Sections and
+// instructions the target never emits are errors.
+func validate(module []byte) error {
+	if !bytes.HasPrefix(module, []byte{0x00, 'a', 's', 'm'}) {
+		return errors.New("wasm: bad magic number")
+	}
+	if len(module) < 8 || !bytes.Equal(module[4:8], []byte{0x01, 0x00, 0x00, 0x00}) {
+		return errors.New("wasm: unsupported version")
+	}
+
+	v := &validator{}
+	r := &reader{b: module[8:]}
+	var last byte
+	for !r.done() {
+		id := r.byte()
+		size := r.u32()
+		content := r.bytes(size)
+		if r.err != nil {
+			return errors.New("wasm: section %d: %s", id, r.err)
+		}
+		if id == 0 {
+			// Custom sections can go anywhere and don't affect validation.
+			continue
+		}
+		if id <= last {
+			return errors.New("wasm: section %d out of order after section %d", id, last)
+		}
+		last = id
+		if err := v.section(id, &reader{b: content}); err != nil {
+			return errors.New("wasm: section %d: %s", id, err)
+		}
+	}
+
+	if len(v.funcs) != v.bodies {
+		return errors.New(
+			"wasm: %d functions declared but %d bodies in the code section", len(v.funcs), v.bodies,
+		)
+	}
+	return nil
+}
+
+// Value types, and a type matching any other for code that's unreachable.
+type valType byte
+
+const (
+	anyType valType = 0
+	i32     valType = 0x7f
+	i64     valType = 0x7e
+	f32     valType = 0x7d
+	f64     valType = 0x7c
+)
+
+func (t valType) String() string {
+	switch t {
+	case i32:
+		return "i32"
+	case i64:
+		return "i64"
+	case f32:
+		return "f32"
+	case f64:
+		return "f64"
+	case anyType:
+		return "any"
+	}
+	return fmt.Sprintf("type 0x%02x", byte(t))
+}
+
+type funcType struct {
+	params, results []valType
+}
+
+type globalType struct {
+	typ     valType
+	mutable bool
+}
+
+// What a module declares, collected from its sections in order.
+type validator struct {
+	types    []funcType
+	funcs    []int // type of each function
+	memories int
+	globals  []globalType
+	bodies   int
+}
+
+func (v *validator) section(id byte, r *reader) (err error) {
+	switch id {
+	case secType:
+		err = v.typeSection(r)
+	case secFunction:
+		for n, i := r.u32(), 0; i < n && r.err == nil; i++ {
+			t := r.u32()
+			if r.err == nil && t >= len(v.types) {
+				return errors.New("function %d: type %d out of range", i, t)
+			}
+			v.funcs = append(v.funcs, t)
+		}
+	case secMemory:
+		err = v.memorySection(r)
+	case secGlobal:
+		err = v.globalSection(r)
+	case secExport:
+		err = v.exportSection(r)
+	case secCode:
+		err = v.codeSection(r)
+	default:
+		return errors.Unsupported("sections with id %d", id)
+	}
+	if err != nil {
+		return err
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if !r.done() {
+		return errors.New("%d bytes left over", len(r.b))
+	}
+	return nil
+}
+
+func (v *validator) typeSection(r *reader) error {
+	for n, i := r.u32(), 0; i < n && r.err == nil; i++ {
+		if form := r.byte(); r.err == nil && form != funcForm {
+			return errors.New("type %d: bad form 0x%02x", i, form)
+		}
+		var t funcType
+		var err error
+		if t.params, err = r.valTypes(); err != nil {
+			return errors.New("type %d: %s", i, err)
+		}
+		if t.results, err = r.valTypes(); err != nil {
+			return errors.New("type %d: %s", i, err)
+		}
+		if len(t.results) > 1 {
+			return errors.New("type %d: more than one result", i)
+		}
+		v.types = append(v.types, t)
+	}
+	return nil
+}
+
+func (v *validator) memorySection(r *reader) error {
+	n := r.u32()
+	if r.err == nil && n > 1 {
+		return errors.New("%d memories but at most 1 is allowed", n)
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		max := -1
+		flags := r.byte()
+		min := r.u32()
+		switch flags {
+		case 0x00:
+		case 0x01:
+			max = r.u32()
+		default:
+			return errors.New("memory %d: bad limits 0x%02x", i, flags)
+		}
+		if min > 1<<16 || max > 1<<16 {
+			return errors.New("memory %d: more than 65536 pages", i)
+		}
+		if max >= 0 && max < min {
+			return errors.New("memory %d: maximum %d is less than minimum %d", i, max, min)
+		}
+		v.memories++
+	}
+	return nil
+}
+
+func (v *validator) globalSection(r *reader) error {
+	for n, i := r.u32(), 0; i < n && r.err == nil; i++ {
+		t, err := r.valType()
+		if err != nil {
+			return errors.New("global %d: %s", i, err)
+		}
+		mut := r.byte()
+		if r.err == nil && mut > 1 {
+			return errors.New("global %d: bad mutability 0x%02x", i, mut)
+		}
+
+		// The initializer is a single constant of the global's type.
+		var init valType
+		switch op := r.byte(); op {
+		case opI32Const:
+			r.s32()
+			init = i32
+		case opI64Const:
+			r.s64()
+			init = i64
+		default:
+			if r.err == nil {
+				return errors.New("global %d: initializer isn't a constant", i)
+			}
+		}
+		if end := r.byte(); r.err == nil && end != opEnd {
+			return errors.New("global %d: initializer isn't a single constant", i)
+		}
+		if r.err == nil && init != t {
+			return errors.New("global %d: %s initializer for %s global", i, init, t)
+		}
+		v.globals = append(v.globals, globalType{t, mut == 1})
+	}
+	return nil
+}
+
+func (v *validator) exportSection(r *reader) error {
+	names := make(map[string]bool)
+	for n, i := r.u32(), 0; i < n && r.err == nil; i++ {
+		name := string(r.bytes(r.u32()))
+		kind := r.byte()
+		index := r.u32()
+		if r.err != nil {
+			break
+		}
+		if !utf8.ValidString(name) {
+			return errors.New("export %d: name isn't UTF-8", i)
+		}
+		if names[name] {
+			return errors.New("duplicate export %q", name)
+		}
+		names[name] = true
+
+		var count int
+		switch kind {
+		case exportFunc:
+			count = len(v.funcs)
+		case exportMemory:
+			count = v.memories
+		case exportGlobal:
+			count = len(v.globals)
+		default:
+			return errors.Unsupported("exports of kind %d", kind)
+		}
+		if index >= count {
+			return errors.New("export %q: index %d out of range", name, index)
+		}
+	}
+	return nil
+}
+
+func (v *validator) codeSection(r *reader) error {
+	n := r.u32()
+	if r.err == nil && n != len(v.funcs) {
+		return errors.New("%d bodies for %d functions", n, len(v.funcs))
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		body := r.bytes(r.u32())
+		if r.err != nil {
+			break
+		}
+		if err := v.body(v.types[v.funcs[i]], &reader{b: body}); err != nil {
+			return errors.New("function %d: %s", i, err)
+		}
+		v.bodies++
+	}
+	return nil
+}
+
+// A block, loop, if or function body being validated.
+type frame struct {
+	op          byte
+	results     []valType
+	height      int
+	unreachable bool
+}
+
+// Types of the values a branch to the frame carries.
+func (f frame) labels() []valType {
+	if f.op == opLoop {
+		return nil
+	}
+	return f.results
+}
+
+// Type checks the body of a function, following the validation algorithm of
+// the WebAssembly specification.
+func (v *validator) body(typ funcType, r *reader) error {
+	locals := append([]valType{}, typ.params...)
+	for n, i := r.u32(), 0; i < n && r.err == nil; i++ {
+		count := r.u32()
+		t, err := r.valType()
+		if err != nil {
+			return err
+		}
+		if len(locals)+count > 50000 {
+			return errors.New("too many locals")
+		}
+		for k := 0; k < count; k++ {
+			locals = append(locals, t)
+		}
+	}
+
+	var (
+		vals   []valType
+		frames = []frame{{results: typ.results}}
+	)
+	push := func(ts ...valType) {
+		vals = append(vals, ts...)
+	}
+	pop := func(want valType) error {
+		top := frames[len(frames)-1]
+		if len(vals) == top.height {
+			if top.unreachable {
+				return nil
+			}
+			return errors.New("operand stack underflow")
+		}
+		got := vals[len(vals)-1]
+		vals = vals[:len(vals)-1]
+		if got != want && got != anyType && want != anyType {
+			return errors.New("type mismatch: expected %s but found %s", want, got)
+		}
+		return nil
+	}
+	popAll := func(want []valType) error {
+		for i := len(want) - 1; i >= 0; i-- {
+			if err := pop(want[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	unreachable := func() {
+		top := &frames[len(frames)-1]
+		vals = vals[:top.height]
+		top.unreachable = true
+	}
+	label := func(depth int) (frame, error) {
+		if depth >= len(frames) {
+			return frame{}, errors.New("branch depth %d out of range", depth)
+		}
+		return frames[len(frames)-1-depth], nil
+	}
+	globalAt := func() (globalType, error) {
+		i := r.u32()
+		if r.err == nil && i >= len(v.globals) {
+			return globalType{}, errors.New("global %d out of range", i)
+		}
+		if r.err != nil {
+			return globalType{}, r.err
+		}
+		return v.globals[i], nil
+	}
+	memarg := func(natural int) error {
+		align, _ := r.u32(), r.u32()
+		if v.memories == 0 {
+			return errors.New("memory access without a memory")
+		}
+		if align > natural {
+			return errors.New("alignment 2^%d is more than natural", align)
+		}
+		return nil
+	}
+
+	for len(frames) > 0 {
+		at := r.pos()
+		op := r.byte()
+		if r.err != nil {
+			return errors.New("body ends inside a block")
+		}
+
+		var err error
+		switch {
+		case op == opUnreachable:
+			unreachable()
+		case op == opNop:
+		case op == opBlock || op == opLoop || op == opIf:
+			var results []valType
+			if bt := r.byte(); bt != blockVoid {
+				t := valType(bt)
+				if !validValType(bt) {
+					err = errors.Unsupported("block type 0x%02x", bt)
+					break
+				}
+				results = []valType{t}
+			}
+			if op == opIf {
+				if err = pop(i32); err != nil {
+					break
+				}
+			}
+			frames = append(frames, frame{op: op, results: results, height: len(vals)})
+		case op == opElse:
+			top := &frames[len(frames)-1]
+			if top.op != opIf {
+				err = errors.New("else outside of an if")
+				break
+			}
+			if err = popAll(top.results); err != nil {
+				break
+			}
+			if len(vals) != top.height {
+				err = errors.New("values left on the stack at the end of a block")
+				break
+			}
+			top.op, top.unreachable = opElse, false
+		case op == opEnd:
+			top := frames[len(frames)-1]
+			if err = popAll(top.results); err != nil {
+				break
+			}
+			if len(vals) != top.height {
+				err = errors.New("values left on the stack at the end of a block")
+				break
+			}
+			if top.op == opIf && len(top.results) > 0 {
+				err = errors.New("if without else must not produce values")
+				break
+			}
+			frames = frames[:len(frames)-1]
+			push(top.results...)
+		case op == opBr:
+			var f frame
+			if f, err = label(r.u32()); err == nil {
+				if err = popAll(f.labels()); err == nil {
+					unreachable()
+				}
+			}
+		case op == opBrIf:
+			var f frame
+			if f, err = label(r.u32()); err == nil {
+				if err = pop(i32); err == nil {
+					if err = popAll(f.labels()); err == nil {
+						push(f.labels()...)
+					}
+				}
+			}
+		case op == opBrTable:
+			n := r.u32()
+			depths := make([]int, 0, n)
+			for k := 0; k <= n && r.err == nil; k++ {
+				depths = append(depths, r.u32())
+			}
+			if err = pop(i32); err != nil {
+				break
+			}
+			var arity = -1
+			for _, depth := range depths {
+				var f frame
+				if f, err = label(depth); err != nil {
+					break
+				}
+				if arity >= 0 && len(f.labels()) != arity {
+					err = errors.New("br_table targets carry different numbers of values")
+					break
+				}
+				arity = len(f.labels())
+				if err = popAll(f.labels()); err != nil {
+					break
+				}
+				push(f.labels()...)
+			}
+			if err == nil {
+				unreachable()
+			}
+		case op == opReturn:
+			if err = popAll(typ.results); err == nil {
+				unreachable()
+			}
+		case op == opCall:
+			f := r.u32()
+			if r.err == nil && f >= len(v.funcs) {
+				err = errors.New("call to function %d out of range", f)
+				break
+			}
+			callee := v.types[v.funcs[f]]
+			if err = popAll(callee.params); err == nil {
+				push(callee.results...)
+			}
+		case op == opDrop:
+			err = pop(anyType)
+		case op == opLocalGet || op == opLocalSet || op == opLocalTee:
+			i := r.u32()
+			if r.err == nil && i >= len(locals) {
+				err = errors.New("local %d out of range", i)
+				break
+			}
+			if op == opLocalGet {
+				push(locals[i])
+			} else if err = pop(locals[i]); err == nil && op == opLocalTee {
+				push(locals[i])
+			}
+		case op == opGlobalGet:
+			var g globalType
+			if g, err = globalAt(); err == nil {
+				push(g.typ)
+			}
+		case op == opGlobalSet:
+			var g globalType
+			if g, err = globalAt(); err == nil {
+				if !g.mutable {
+					err = errors.New("global.set of an immutable global")
+					break
+				}
+				err = pop(g.typ)
+			}
+		case op == opI32Load || op == opI64Load:
+			t, natural := i32, 2
+			if op == opI64Load {
+				t, natural = i64, 3
+			}
+			if err = memarg(natural); err == nil {
+				if err = pop(i32); err == nil {
+					push(t)
+				}
+			}
+		case op == opI32Store || op == opI64Store:
+			t, natural := i32, 2
+			if op == opI64Store {
+				t, natural = i64, 3
+			}
+			if err = memarg(natural); err == nil {
+				if err = pop(t); err == nil {
+					err = pop(i32)
+				}
+			}
+		case op == opI32Const:
+			r.s32()
+			push(i32)
+		case op == opI64Const:
+			r.s64()
+			push(i64)
+		case op == opI32Eqz || op == opI64Eqz:
+			t := i32
+			if op == opI64Eqz {
+				t = i64
+			}
+			if err = pop(t); err == nil {
+				push(i32)
+			}
+		case op >= 0x46 && op <= 0x4f, op >= 0x51 && op <= 0x5a: // comparisons
+			t := i32
+			if op >= 0x51 {
+				t = i64
+			}
+			if err = popAll([]valType{t, t}); err == nil {
+				push(i32)
+			}
+		case op >= 0x6a && op <= 0x78, op >= 0x7c && op <= 0x8a: // arithmetic
+			t := i32
+			if op >= 0x7c {
+				t = i64
+			}
+			if err = popAll([]valType{t, t}); err == nil {
+				push(t)
+			}
+		default:
+			err = errors.Unsupported("opcode 0x%02x", op)
+		}
+		if err == nil {
+			err = r.err
+		}
+		if err != nil {
+			return errors.New("byte %d (opcode 0x%02x): %s", at, op, err)
+		}
+	}
+
+	if !r.done() {
+		return errors.New("%d bytes after the end of the body", len(r.b))
+	}
+	return nil
+}
+
+func validValType(b byte) bool {
+	t := valType(b)
+	return t == i32 || t == i64 || t == f32 || t == f64
+}
+
+// Reads the binary format. The first error sticks, and later reads return
+// zero values.
+type reader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (r *reader) done() bool {
+	return len(r.b) == 0
+}
+
+// Returns the offset of the next byte.
+func (r *reader) pos() int {
+	return r.off
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.b) == 0 {
+		r.err = errors.New("unexpected end")
+		return 0
+	}
+	c := r.b[0]
+	r.b, r.off = r.b[1:], r.off+1
+	return c
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.b) {
+		r.err = errors.New("size %d runs past the end", n)
+		return nil
+	}
+	out := r.b[:n]
+	r.b, r.off = r.b[n:], r.off+n
+	return out
+}
+
+// Reads an unsigned LEB128 number of at most 32 bits.
+func (r *reader) u32() int {
+	var n uint64
+	for shift := uint(0); ; shift += 7 {
+		c := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		if shift == 28 && c > 0x0f {
+			r.err = errors.New("u32 out of range")
+			return 0
+		}
+		n |= uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return int(n)
+		}
+	}
+}
+
+// Reads a signed LEB128 number of at most bits bits.
+func (r *reader) signed(bits uint) int64 {
+	var n int64
+	var shift uint
+	for {
+		c := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		if shift >= bits {
+			r.err = errors.New("s%d out of range", bits)
+			return 0
+		}
+		n |= int64(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			if shift < 64 && c&0x40 != 0 {
+				n |= -1 << shift
+			}
+			return n
+		}
+	}
+}
+
+func (r *reader) s32() int64 {
+	return r.signed(32)
+}
+
+func (r *reader) s64() int64 {
+	return r.signed(64)
+}
+
+func (r *reader) valType() (valType, error) {
+	t := r.byte()
+	if r.err != nil {
+		return 0, r.err
+	}
+	if !validValType(t) {
+		return 0, errors.New("bad value type 0x%02x", t)
+	}
+	return valType(t), nil
+}
+
+func (r *reader) valTypes() ([]valType, error) {
+	ts := make([]valType, 0)
+	for n, i := r.u32(), 0; i < n && r.err == nil; i++ {
+		t, err := r.valType()
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	return ts, r.err
+}